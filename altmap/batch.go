@@ -0,0 +1,98 @@
+package altmap
+
+// batchSize is the number of keys of GetBatch and AddBatch whose memory
+// loads are overlapped.
+const batchSize = 16
+
+/*
+A lookup in a large cache is dominated by the cache misses of the load of
+its table pointer in the directory and of the groups it probes. GetBatch and
+AddBatch process the keys by batches of batchSize keys in stages: the keys
+are hashed, then their table pointers are loaded, then the headers of their
+first groups are loaded. The loads of a stage are independent, so that the
+CPU overlaps their cache misses, and the following probes of the keys find
+the groups in the CPU cache.
+
+The loaded headers are used to skip the probes of the keys that are not in
+the cache when their first group has no matching top hash and a free slot.
+A header is valid while the number of items of its table is unchanged, as
+the header of a table without eviction policy, expiration times and split
+in progress only changes when an item is added.
+*/
+
+// batch holds the stages of a batch of keys.
+type batch[K comparable, V any] struct {
+	hashes [batchSize]uint
+	tables [batchSize]*table[K, V]
+	nItems [batchSize]uint32
+	hdrs   [batchSize]Hdr
+}
+
+// load hashes the keys, at most batchSize, and loads their tables and the
+// headers of their first groups.
+func (b *batch[K, V]) load(c *Cache[K, V], keys []K) {
+	for i, key := range keys {
+		b.hashes[i] = c.hasher.Hash(c.seed, key)
+	}
+	for i := range keys {
+		b.tables[i] = c.table(b.hashes[i])
+	}
+	for i := range keys {
+		t := b.tables[i]
+		b.nItems[i] = t.nItems
+		b.hdrs[i] = t.firstGroup(b.hashes[i]).header
+	}
+}
+
+// absent returns true if the key i of the batch is known not to be in the
+// cache from the header loaded by load.
+func (b *batch[K, V]) absent(c *Cache[K, V], i int) bool {
+	t, hash, hdr := b.tables[i], b.hashes[i], b.hdrs[i]
+	return c.fast(t) && c.table(hash) == t && t.nItems == b.nItems[i] &&
+		hdr.Find(MakePattern(H2(hash))).Empty() && hdr.HasFreeSlots()
+}
+
+// GetBatch stores in values[i] and found[i] the value associated to keys[i]
+// and true if it is found, as Get does. The memory loads of the lookups of
+// the keys are overlapped. It panics if values or found is shorter than
+// keys.
+func (c *Cache[K, V]) GetBatch(keys []K, values []V, found []bool) {
+	if len(values) < len(keys) || len(found) < len(keys) {
+		panic("altmap: GetBatch values and found must be as long as keys")
+	}
+	var b batch[K, V]
+	for len(keys) > 0 {
+		n := min(len(keys), batchSize)
+		b.load(c, keys[:n])
+		for i, key := range keys[:n] {
+			if b.absent(c, i) {
+				values[i], found[i] = *new(V), false
+			} else {
+				values[i], found[i] = c.GetHashed(key, b.hashes[i])
+			}
+		}
+		keys, values, found = keys[n:], values[n:], found[n:]
+	}
+}
+
+// AddBatch adds keys[i] with values[i] to the cache, as Add does, in the
+// order of the keys. The memory loads of the lookups of the keys are
+// overlapped. It panics if values is shorter than keys.
+func (c *Cache[K, V]) AddBatch(keys []K, values []V) {
+	if len(values) < len(keys) {
+		panic("altmap: AddBatch values must be as long as keys")
+	}
+	var b batch[K, V]
+	for len(keys) > 0 {
+		n := min(len(keys), batchSize)
+		b.load(c, keys[:n])
+		for i, key := range keys[:n] {
+			if b.absent(c, i) {
+				c.insert(b.tables[i], key, values[i], b.hashes[i])
+			} else {
+				c.AddHashed(key, values[i], b.hashes[i])
+			}
+		}
+		keys, values = keys[n:], values[n:]
+	}
+}
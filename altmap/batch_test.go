@@ -0,0 +1,106 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestBatch checks GetBatch and AddBatch against a go map with batches of
+// random sizes that may repeat keys.
+func TestBatch(t *testing.T) {
+	for name, opts := range upsertOptions {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+			c := NewCache[string, int](20000, opts...)
+			m := map[string]int{}
+			for i := range 2000 {
+				n := rng.IntN(3 * batchSize)
+				keys := make([]string, n)
+				values := make([]int, n)
+				for j := range keys {
+					keys[j] = str(rng.IntN(20000))
+					values[j] = rng.IntN(1000)
+				}
+				if i%2 == 0 {
+					c.AddBatch(keys, values)
+					for j, k := range keys {
+						m[k] = values[j]
+					}
+					if c.Len() != len(m) {
+						t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+					}
+					continue
+				}
+				found := make([]bool, n)
+				c.GetBatch(keys, values, found)
+				for j, k := range keys {
+					if v, ok := m[k]; found[j] != ok || values[j] != v {
+						t.Fatalf("%d key %q expect %d %v, got %d %v", i, k, v, ok, values[j], found[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkBatch measures the lookup of size keys that are found (hit) or
+// not (miss), one at a time with Get and by batches with GetBatch, and the
+// addition of size keys with Add and with AddBatch.
+func BenchmarkBatch(b *testing.B) {
+	size := cacheSizes[len(cacheSizes)-1]
+	ss := make([]string, size)
+	us := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+		us[i] = strB(i)
+	}
+	const n = 1024
+	values := make([]int, n)
+	found := make([]bool, n)
+	for _, size := range cacheSizes[5:] {
+		rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+		hits := make([]string, size)
+		for i := range hits {
+			hits[i] = ss[rng.IntN(size)]
+		}
+		var c Cache[string, int]
+		c.Init()
+		for i := range size {
+			c.Add(ss[i], i)
+		}
+		for _, k := range []struct {
+			name string
+			keys []string
+		}{{"hit", hits}, {"miss", us[:size]}} {
+			b.Run(fmt.Sprintf("get/%s/%8d", k.name, size), func(b *testing.B) {
+				for i := range b.N {
+					c.Get(k.keys[i%size])
+				}
+			})
+			b.Run(fmt.Sprintf("getbatch/%s/%8d", k.name, size), func(b *testing.B) {
+				for i := 0; i < b.N; i += n {
+					j := i % (size - n + 1)
+					c.GetBatch(k.keys[j:j+n], values, found)
+				}
+			})
+		}
+		b.Run(fmt.Sprintf("add/%8d", size), func(b *testing.B) {
+			for range b.N {
+				var c Cache[string, int]
+				c.Init()
+				for i := range size {
+					c.Add(ss[i], i)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("addbatch/%8d", size), func(b *testing.B) {
+			vs := make([]int, size)
+			for range b.N {
+				var c Cache[string, int]
+				c.Init()
+				c.AddBatch(ss[:size], vs)
+			}
+		})
+	}
+}
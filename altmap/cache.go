@@ -1,38 +1,174 @@
 package altmap
 
 import (
+	"time"
 	"unsafe"
 )
 
-// Cache is a map using an extensible directory to tables of tableSize groups.
+// Cache is a map using an extensible directory to tables of tableSize groups,
+// or of the number of groups set by WithTableSizeLog2.
 // The table uses 8bit top hashes with tombstones and doesn't move items.
-// A table is split when it contains more than maxItems.
-type Cache struct {
-	tables  []*table // directory of tables
-	seed    Seed     // hash seed
-	nItems  int      // number of stored items
-	depth   byte     // depth of the directory
-	mask    uint     // mask for hash
-	basePtr **table  // pointer on first entry in tables
-}
-
-func (c *Cache) Init() {
-	c.tables = []*table{newTable(0)}
+// A table is split when it contains more than maxUsed items, and merged with
+// its buddy table when it contains less than minUsed items.
+type Cache[K comparable, V any] struct {
+	tables  []*table[K, V] // directory of tables
+	seed    Seed           // hash seed
+	hasher  Hasher[K]      // hasher of keys
+	nItems  int            // number of stored items
+	depth   byte           // depth of the directory
+	floor   byte           // depth of the tables not merged by Del
+	mask    uint           // mask for hash
+	basePtr **table[K, V]  // pointer on first entry in tables
+
+	policy   policy[K, V] // eviction policy, nil if unbounded
+	maxItems int          // maximum number of items with an eviction policy
+	onEvict  func(K, V)   // eviction callback, may be nil
+
+	now   func() time.Time // clock used for expiration
+	sweep hand             // position of the expiration sweep
+
+	incremental bool               // true if tables are split incrementally
+	splits      []*migration[K, V] // tables being split, oldest first
+	next        []*table[K, V]     // next directory of twice the size, nil if none
+	copied      int                // number of entries copied to the next directory
+
+	debug debugState[K, V] // tables checked for stale pointers in debug builds
+}
+
+// Init initializes an unbounded cache with the default hasher for K.
+// String keys are hashed with xxh3, integer keys with HashUint64
+// and other keys with maphash.
+func (c *Cache[K, V]) Init() {
+	c.InitHasher(defaultHasher[K]())
+}
+
+// InitHasher initializes an unbounded cache with the given hasher.
+func (c *Cache[K, V]) InitHasher(hasher Hasher[K]) {
+	c.tables = []*table[K, V]{newTable[K, V](0)}
 	c.seed = MakeSeed()
+	c.hasher = hasher
 	c.nItems = 0
 	c.depth = 0
+	c.floor = 0
 	c.mask = 0
 	c.basePtr = unsafe.SliceData(c.tables)
+	c.policy = nil
+	c.maxItems = 0
+	c.onEvict = nil
+	c.now = time.Now
+	c.sweep = hand{}
+	c.incremental = false
+	c.splits = nil
+	c.next = nil
+	c.copied = 0
+	c.debug = debugState[K, V]{}
+}
+
+// depthFor returns the directory depth where n items are added without
+// splitting a table. The expected number of items per table is 7/8 of
+// maxUsed to leave room for its deviation, and at least one for the tables
+// of a custom geometry with a tiny maxUsed.
+func (c *Cache[K, V]) depthFor(n int) byte {
+	perTable := max(c.tables[0].limits().maxUsed*7/8, 1)
+	var depth byte
+	for n > perTable<<depth {
+		depth++
+	}
+	return depth
+}
+
+// presize replaces the single table of an empty cache by a directory of the
+// given depth to distinct tables with the same per slot arrays. The depth is
+// the floor below which Del doesn't merge the tables.
+func (c *Cache[K, V]) presize(depth byte) {
+	if depth == 0 {
+		return
+	}
+	t := c.tables[0]
+	c.tables = make([]*table[K, V], 1<<depth)
+	for i := range c.tables {
+		c.tables[i] = t.derive(depth)
+	}
+	c.depth = depth
+	c.floor = depth
+	c.mask = (uint(len(c.tables)) - 1) * 8 // pre multiply mask by pointer byte size
+	c.basePtr = unsafe.SliceData(c.tables)
+	c.next = nil
 }
 
-// Len returns the number of items stored in the cache.
-func (c *Cache) Len() int {
+// Clear deletes all the items of the cache. The directory and the tables
+// are kept and cleared in place to be reused: Del doesn't merge the tables
+// below the depth of the directory, until Compact or Reset.
+func (c *Cache[K, V]) Clear() {
+	c.finishSplits()
+	c.floor = c.depth
+	for i, t := range c.tables {
+		if uint(i) < 1<<t.depth {
+			t.clear()
+		}
+	}
+	c.nItems = 0
+	c.sweep = hand{}
+	if c.policy != nil {
+		c.policy.clear()
+	}
+}
+
+// Reset deletes all the items of the cache and shrinks it to a single
+// table that is reused. The other tables are released.
+func (c *Cache[K, V]) Reset() {
+	c.finishSplits()
+	for i, t := range c.tables[1:] {
+		if uint(i+1) < 1<<t.depth {
+			c.retire(t)
+		}
+	}
+	t := c.tables[0]
+	t.clear()
+	t.depth = 0
+	clear(c.tables[1:])
+	c.tables = c.tables[:1]
+	c.next = nil
+	c.depth = 0
+	c.floor = 0
+	c.mask = 0
+	c.nItems = 0
+	c.sweep = hand{}
+	if c.policy != nil {
+		c.policy.clear()
+	}
+}
+
+// clone returns a copy of the cache with its own tables. Requires the cache
+// has no eviction policy.
+func (c *Cache[K, V]) clone() *Cache[K, V] {
+	c.finishSplits()
+	c2 := *c
+	c2.tables = make([]*table[K, V], len(c.tables))
+	for i, t := range c.tables {
+		if uint(i) < 1<<t.depth {
+			c2.tables[i] = t.clone()
+		} else {
+			// the first index of the table was already visited
+			c2.tables[i] = c2.tables[uint(i)&(1<<t.depth-1)]
+		}
+	}
+	c2.basePtr = unsafe.SliceData(c2.tables)
+	c2.splits = nil
+	c2.next = nil
+	c2.debug = debugState[K, V]{}
+	return &c2
+}
+
+// Len returns the number of items stored in the cache. Expired items not
+// yet deleted are counted.
+func (c *Cache[K, V]) Len() int {
 	return c.nItems
 }
 
 // Cap returns the number of item slots in the cache.
-func (c *Cache) Cap() int {
-	return len(c.tables) * tableItems
+func (c *Cache[K, V]) Cap() int {
+	return len(c.tables) * c.tables[0].cap()
 }
 
 // H0 returns the hash used for the directory.
@@ -41,59 +177,197 @@ func H0(hash uint) uint {
 }
 
 // table return pointer on the table corresponding to the given hash value.
-func (c *Cache) table(hash uint) *table {
+func (c *Cache[K, V]) table(hash uint) *table[K, V] {
 	offset := (hash >> (tableHashBits - 3)) & c.mask
-	return *(**table)(unsafe.Add(unsafe.Pointer(c.basePtr), offset))
+	return *(**table[K, V])(unsafe.Add(unsafe.Pointer(c.basePtr), offset))
+}
+
+// Hash returns the hash of key used by the cache. It may be given to
+// GetHashed, AddHashed and DelHashed of the cache, or of any cache with the
+// same seed, set by WithSeed, and the same hasher.
+func (c *Cache[K, V]) Hash(key K) uint {
+	return c.hasher.Hash(c.seed, key)
 }
 
 // Get returns the value associated to key and true if it is found.
-func (c *Cache) Get(key string) (value int, ok bool) {
-	hash := c.seed.Hash(key)
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	return c.GetHashed(key, c.hasher.Hash(c.seed, key))
+}
+
+// GetHashed is Get where hash is the hash of key returned by Hash. With
+// another hash value the key may not be found.
+func (c *Cache[K, V]) GetHashed(key K, hash uint) (value V, ok bool) {
 	t := c.table(hash)
-	pattern := MakePattern(H2(hash))
-	var pos uint32
-	idx := H1(hash) & (tableSize - 1)
-	offset := uint32(idx) * sizeGroup
-	basePtr := unsafe.Pointer(unsafe.SliceData(t.groups[:]))
-	for {
-		g := (*Group)(unsafe.Add(basePtr, offset))
-		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
-			if item := &g.item[set.Pos()&(nItems-1)]; item.key == key {
-				return item.value, true
-			}
-		}
-		if g.header.HasFreeSlots() {
-			return
-		}
-		// to avoid a product by groupSize or a modulo
-		// pos never reach sizeGroups
-		pos += sizeGroup
-		if offset += pos; offset >= sizeGroups {
-			offset -= sizeGroups
-		}
+	if c.fast(t) {
+		return t.get(key, hash)
+	}
+	t, slot := c.lookup(t, key, hash)
+	if slot < 0 {
+		return
 	}
+	if c.policy != nil {
+		c.policy.hit(t, slot)
+	}
+	return t.item(slot).value, true
 }
 
 // Add swaps the value and return true if the key is found in the cache,
 // otherwise it adds the key and value and returns false.
-func (c *Cache) Add(key string, value int) (oldValue int, ok bool) {
-	hash := c.seed.Hash(key)
+func (c *Cache[K, V]) Add(key K, value V) (oldValue V, ok bool) {
+	return c.AddHashed(key, value, c.hasher.Hash(c.seed, key))
+}
+
+// AddHashed is Add where hash is the hash of key returned by Hash. With
+// another hash value the key may be added twice.
+func (c *Cache[K, V]) AddHashed(key K, value V, hash uint) (oldValue V, ok bool) {
 	t := c.table(hash)
-	if oldValue, ok = t.swap(&key, value, hash); ok {
+	if !c.fast(t) {
+		return c.add(t, key, value, hash, 0)
+	}
+	if oldValue, ok = t.swap(key, value, hash); ok {
 		return
 	}
+	c.insert(t, key, value, hash)
+	return
+}
+
+// add is Add when the cache has an eviction policy, or t has expiration
+// times or is being split. expires is the expiration time of the item in
+// Unix nanoseconds, or 0 if it doesn't expire.
+func (c *Cache[K, V]) add(t *table[K, V], key K, value V, hash uint, expires int64) (oldValue V, ok bool) {
+	t, slot := c.lookup(t, key, hash)
+	if slot >= 0 {
+		item := t.item(slot)
+		oldValue, item.value = item.value, value
+		t.setExpires(slot, expires)
+		if c.policy != nil {
+			c.policy.hit(t, slot)
+		}
+		return oldValue, true
+	}
+	t, slot = c.addNew(t, key, value, hash)
+	t.setExpires(slot, expires)
+	return
+}
 
-	for !t.add(&key, value, hash) {
+// addNew adds the key and value in the cache, evicting an item first if
+// the cache is full, and returns the table and slot index of the item.
+// Requires the key is not in the cache and t is the table of hash.
+func (c *Cache[K, V]) addNew(t *table[K, V], key K, value V, hash uint) (*table[K, V], int) {
+	if c.policy != nil && c.nItems >= c.maxItems {
+		c.evict()
+		t = c.table(hash)
+	}
+	t, slot := c.insert(t, key, value, hash)
+	if t.expires != nil {
+		t.expires[slot] = 0
+	}
+	if c.policy != nil {
+		c.policy.added(t, slot, key, hash)
+	}
+	return t, slot
+}
+
+// upsert returns the table and slot of key with the given hash and true if
+// it is found, otherwise it adds the key with the zero value and returns its
+// table and slot and false. A found key is a hit for the eviction policy.
+func (c *Cache[K, V]) upsert(key K, hash uint) (*table[K, V], int, bool) {
+	t := c.table(hash)
+	if c.fast(t) {
+		slot, found := t.probe(key, hash)
+		if found {
+			return t, slot, true
+		}
+		t, slot = c.insertAt(t, slot, key, *new(V), hash)
+		return t, slot, false
+	}
+	t, slot := c.lookup(t, key, hash)
+	if slot >= 0 {
+		if c.policy != nil {
+			c.policy.hit(t, slot)
+		}
+		return t, slot, true
+	}
+	t, slot = c.addNew(t, key, *new(V), hash)
+	return t, slot, false
+}
+
+// fast returns true if the items of table t may be found and added with a
+// single probe of t, that is without an eviction policy, expiration times
+// and a table being split into t.
+func (c *Cache[K, V]) fast(t *table[K, V]) bool {
+	return c.policy == nil && t.expires == nil && t.migration == nil
+}
+
+// lookup returns the table and slot of the item with the given key and hash
+// in table t, or t and -1 if it is not found. An expired item is deleted and
+// not found.
+func (c *Cache[K, V]) lookup(t *table[K, V], key K, hash uint) (*table[K, V], int) {
+	t, slot := t.lookup(key, hash)
+	if slot >= 0 && t.expires != nil && c.expired(t, slot) {
+		c.expire(t, slot, hash)
+		return c.table(hash), -1
+	}
+	return t, slot
+}
+
+// insertAt adds the key and value in the unused slot of t returned by probe,
+// or with insert if t is full, and returns the table and slot index of the
+// item. Requires the conditions of fast.
+func (c *Cache[K, V]) insertAt(t *table[K, V], slot int, key K, value V, hash uint) (*table[K, V], int) {
+	if !t.claim(slot, key, value, hash) {
+		return c.insert(t, key, value, hash)
+	}
+	c.nItems++
+	if c.incremental {
+		// the split of another table progresses as with insert
+		c.migrate(t)
+	}
+	return t, slot
+}
+
+// evict deletes the item selected by the eviction policy and calls the
+// eviction callback.
+func (c *Cache[K, V]) evict() {
+	key := c.policy.victim(c)
+	hash := c.hasher.Hash(c.seed, key)
+	t, slot := c.table(hash).lookup(key, hash)
+	value := c.delAt(t, slot, hash)
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+// insert adds the key and value in table t, splitting it if needed. Requires
+// the key is not in the cache and t is the table of hash. Returns the table
+// and slot index where the item is stored.
+func (c *Cache[K, V]) insert(t *table[K, V], key K, value V, hash uint) (*table[K, V], int) {
+	if c.incremental {
+		c.migrate(t)
+	}
+	slot := t.insert(key, value, hash)
+	for slot < 0 {
+		if m := t.migration; m != nil {
+			// the table is full before the end of its split
+			c.copyItems(m, m.old.cap())
+			slot = t.insert(key, value, hash)
+			continue
+		}
 
 		// the table is full, it must be split
 		l := uint(len(c.tables))
 		if t.depth == c.depth {
 			// grow the directory
-			tables := c.tables
 			l2 := l * 2
-			c.tables = make([]*table, l2)
-			copy(c.tables, tables)
-			copy(c.tables[l:], tables)
+			if c.next != nil {
+				c.copyDir(int(l))
+				c.tables, c.next = c.next, nil
+			} else {
+				tables := c.tables
+				c.tables = make([]*table[K, V], l2)
+				copy(c.tables, tables)
+				copy(c.tables[l:], tables)
+			}
 			c.depth++
 			c.mask = (l2 - 1) * 8 // pre multiply mask by pointer byte size
 			c.basePtr = unsafe.SliceData(c.tables)
@@ -102,34 +376,188 @@ func (c *Cache) Add(key string, value int) (oldValue int, ok bool) {
 
 		step := uint(1 << t.depth)    // interval between pointers to the table
 		tIdx := H0(hash) & (step - 1) // index to the first table pointer in the table
-		t1, t2 := t.split(step, c.seed)
+		var t1, t2 *table[K, V]
+		if c.incremental {
+			t1, t2 = c.splitIncremental(t)
+		} else {
+			t1, t2 = t.split(step, c.seed, c.hasher)
+			c.retire(t)
+		}
 
-		for tIdx < l {
-			c.tables[tIdx] = t1
-			tIdx += step
-			c.tables[tIdx] = t2
-			tIdx += step
+		for i := tIdx; i < l; {
+			c.tables[i] = t1
+			i += step
+			c.tables[i] = t2
+			i += step
 		}
+		c.mirror(tIdx, step)
 
 		t = c.table(hash)
+		slot = t.insert(key, value, hash)
 	}
 	c.nItems++
-	return
+	return t, slot
 }
 
 // Del deletes key from the cache.
-func (c *Cache) Del(key string) {
-	hash := c.seed.Hash(key)
-	t := c.table(hash)
-	rehash, ok := t.del(&key, hash)
-	if ok {
-		c.nItems--
-		if rehash {
-			t2 := t.rehash(c.seed)
-			step := uint(1 << t.depth) // interval between pointers to the table
-			for tIdx, l := H0(hash)&(step-1), uint(len(c.tables)); tIdx < l; tIdx += step {
-				c.tables[tIdx] = t2
+func (c *Cache[K, V]) Del(key K) {
+	c.del(key, c.hasher.Hash(c.seed, key))
+}
+
+// DelHashed is Del where hash is the hash of key returned by Hash.
+func (c *Cache[K, V]) DelHashed(key K, hash uint) {
+	c.del(key, hash)
+}
+
+// Take deletes key from the cache and returns its value and true if it was
+// found. The key is found and deleted by a single probe sequence.
+func (c *Cache[K, V]) Take(key K) (value V, ok bool) {
+	return c.del(key, c.hasher.Hash(c.seed, key))
+}
+
+// DelMany deletes the keys from the cache and returns the number of keys
+// that were found.
+func (c *Cache[K, V]) DelMany(keys []K) int {
+	var n int
+	for _, key := range keys {
+		if _, ok := c.del(key, c.hasher.Hash(c.seed, key)); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// del deletes key with the given hash from the cache and returns its value
+// and true if it was found. An expired item is deleted as by Get and not
+// found.
+func (c *Cache[K, V]) del(key K, hash uint) (value V, ok bool) {
+	t, slot := c.lookup(c.table(hash), key, hash)
+	if slot < 0 {
+		return
+	}
+	return c.delAt(t, slot, hash), true
+}
+
+// delAt deletes the item with the given hash in the used slot of t and
+// returns its value. t is the table of hash, or the table being split into
+// it where the item is live. Tables are merged or rehashed after the
+// deletion if needed.
+func (c *Cache[K, V]) delAt(t *table[K, V], slot int, hash uint) V {
+	if t.splitting() {
+		// the item is live in the table being split
+		return c.delSlot(t, slot)
+	}
+	value := c.delSlot(t, slot)
+	if int(t.nItems) < t.limits().minUsed && t.depth > c.floor && c.merge(t, H0(hash)) {
+		return value
+	}
+	if int(t.nTombstones) > t.limits().maxTombstones {
+		c.rehash(t, H0(hash))
+	}
+	return value
+}
+
+// delSlot deletes the item in the given used slot of t and returns its value.
+// The table is not rehashed when the number of tombstones exceeds the threshold.
+func (c *Cache[K, V]) delSlot(t *table[K, V], slot int) V {
+	if c.policy != nil {
+		c.policy.removed(t, slot)
+	}
+	value, _ := t.delSlot(slot)
+	c.nItems--
+	return value
+}
+
+// rehash replaces table t with its rehashed copy in the directory. h0 is
+// the directory hash of any key of t.
+func (c *Cache[K, V]) rehash(t *table[K, V], h0 uint) {
+	if m := t.migration; m != nil {
+		c.copyItems(m, m.old.cap())
+	}
+	t2 := t.rehash(c.seed, c.hasher)
+	c.retire(t)
+	step := uint(1 << t.depth) // interval between pointers to the table
+	for tIdx, l := h0&(step-1), uint(len(c.tables)); tIdx < l; tIdx += step {
+		c.tables[tIdx] = t2
+	}
+	c.mirror(h0&(step-1), step)
+}
+
+// Compact merges the buddy tables whose items fit in a half full table and
+// halves the directory while no table has its depth. Del merges the tables
+// automatically, but DeleteFunc and Sweep don't, and Del doesn't merge the
+// tables below the depth allocated by the size hint of NewCache or kept by
+// Clear. Compact merges them and removes this floor.
+func (c *Cache[K, V]) Compact() {
+	c.finishSplits()
+	c.floor = 0
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(c.tables); i++ {
+			if t := c.tables[i]; uint(i) < 1<<t.depth && c.merge(t, uint(i)) {
+				merged = true
+			}
+		}
+	}
+}
+
+// merge replaces table t and its buddy table with their merged table in the
+// directory if they have the same depth and the merged table is at most half
+// full. The buddy table has the same directory hash as t but for its bit
+// t.depth-1. h0 is the directory hash of any key of t. Returns true if the
+// tables were merged.
+func (c *Cache[K, V]) merge(t *table[K, V], h0 uint) bool {
+	if t.depth == 0 || t.migration != nil {
+		return false
+	}
+	step := uint(1) << (t.depth - 1) // interval between pointers to the merged table
+	buddy := c.tables[(h0^step)&(2*step-1)]
+	if buddy.depth != t.depth || buddy.migration != nil || int(t.nItems)+int(buddy.nItems) > t.limits().maxMerged {
+		return false
+	}
+	t2 := t.merge(buddy, c.seed, c.hasher)
+	c.retire(t)
+	c.retire(buddy)
+	for tIdx, l := h0&(step-1), uint(len(c.tables)); tIdx < l; tIdx += step {
+		c.tables[tIdx] = t2
+	}
+	c.mirror(h0&(step-1), step)
+	if t.depth == c.depth {
+		c.shrink()
+	}
+	return true
+}
+
+// shrink halves the directory while no table has its depth.
+func (c *Cache[K, V]) shrink() {
+	for c.depth > 0 {
+		for _, t := range c.tables {
+			if t.depth == c.depth {
+				return
 			}
 		}
+		// the upper half of the directory is a copy of the lower half
+		l := uint(len(c.tables)) / 2
+		clear(c.tables[l:])
+		c.tables = c.tables[:l]
+		c.next = nil
+		c.depth--
+		c.mask = (l - 1) * 8 // pre multiply mask by pointer byte size
 	}
 }
+
+// hand is a position in the slots of the cache tables given by the directory
+// index of the table and the slot index in the table.
+//
+// A table at depth d appears at the directory indexes i+k<<d with i < 1<<d.
+// A hand skips the indexes i >= 1<<d to visit each table once per turn.
+type hand struct {
+	tIdx uint // directory index of the table under the hand
+	slot int  // slot index under the hand
+}
+
+// next moves the hand to the first slot of the next table.
+func (h *hand) next() {
+	h.tIdx++
+	h.slot = 0
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"testing"
+	"time"
 )
 
 func str(i int) string {
@@ -15,7 +16,7 @@ func strB(i int) string {
 }
 
 func TestCacheAddGet(t *testing.T) {
-	var c Cache
+	var c Cache[string, int]
 	c.Init()
 	ss := []string{}
 	for i := range 5000 {
@@ -51,7 +52,7 @@ func TestCacheAddGet(t *testing.T) {
 }
 
 func TestCacheAddDel(t *testing.T) {
-	var c Cache
+	var c Cache[string, int]
 	c.Init()
 	ss := []string{}
 	for i := range 5000 {
@@ -87,11 +88,304 @@ func TestCacheAddDel(t *testing.T) {
 	}
 }
 
+func TestCacheTake(t *testing.T) {
+	for name, opts := range upsertOptions {
+		t.Run(name, func(t *testing.T) {
+			const n = 20000
+			c := NewCache[string, int](n, opts...)
+			for i := range n {
+				c.Add(str(i), i)
+			}
+			for i := 0; i < n; i += 2 {
+				if v, ok := c.Take(str(i)); !ok || v != i {
+					t.Fatalf("key %q expect %d true, got %d %v", str(i), i, v, ok)
+				}
+			}
+			if v, ok := c.Take(str(0)); ok {
+				t.Fatalf("key %q expect not found, got %d", str(0), v)
+			}
+			keys := make([]string, 0, n)
+			for i := range n {
+				if i%4 < 2 {
+					keys = append(keys, str(i))
+				}
+			}
+			// half of the keys were taken
+			if got := c.DelMany(keys); got != n/4 {
+				t.Fatalf("expect %d keys deleted, got %d", n/4, got)
+			}
+			if c.Len() != n/4 {
+				t.Fatalf("expect len %d, got %d", n/4, c.Len())
+			}
+			for i := range n {
+				if v, ok := c.Get(str(i)); ok != (i%4 == 3) || ok && v != i {
+					t.Fatalf("key %q expect %v, got %d %v", str(i), i%4 == 3, v, ok)
+				}
+			}
+		})
+	}
+	t.Run("ttl", func(t *testing.T) {
+		const n = 1000
+		clk := &fakeClock{t: time.Unix(1000, 0)}
+		var evicted []int
+		c := NewCache[int, int](0, WithClock(clk.now), WithEvictCallback(func(k, v int) {
+			evicted = append(evicted, v)
+		}))
+		for i := range n {
+			c.AddWithTTL(i, i, time.Duration(i%2+1)*time.Second)
+		}
+		clk.t = clk.t.Add(time.Second)
+		// the even keys expired
+		if v, ok := c.Take(0); ok {
+			t.Fatalf("expired key 0 expect not found, got %d", v)
+		}
+		if v, ok := c.Take(1); !ok || v != 1 {
+			t.Fatalf("key 1 expect 1 true, got %d %v", v, ok)
+		}
+		keys := make([]int, 0, n)
+		for i := 2; i < n; i++ {
+			keys = append(keys, i)
+		}
+		if got := c.DelMany(keys); got != n/2-1 {
+			t.Fatalf("expect %d keys deleted, got %d", n/2-1, got)
+		}
+		if c.Len() != 0 || len(evicted) != n/2 {
+			t.Fatalf("expect empty cache and %d expired, got %d and %d", n/2, c.Len(), len(evicted))
+		}
+		for _, v := range evicted {
+			if v%2 != 0 {
+				t.Fatalf("expect even expired values, got %d", v)
+			}
+		}
+	})
+}
+
+// TestCacheHashed checks that a hash computed by a cache is reused by the
+// caches with the same seed and hasher.
+func TestCacheHashed(t *testing.T) {
+	const n = 5000
+	seed := MakeSeed()
+	c1 := NewCache[string, int](0, WithSeed(seed))
+	c2 := NewCache[string, int](n, WithSeed(seed), WithLRU())
+	type point struct{ x, y int }
+	hasher := NewMapHasher[point]()
+	p1 := NewCache[point, int](0, WithSeed(seed), WithHasher[point](hasher))
+	p2 := NewCache[point, int](0, WithSeed(seed), WithHasher[point](hasher))
+	for i := range n {
+		h := c1.Hash(str(i))
+		if h2 := c2.Hash(str(i)); h != h2 {
+			t.Fatalf("key %q expect hash %x, got %x", str(i), h, h2)
+		}
+		c1.AddHashed(str(i), i, h)
+		c2.AddHashed(str(i), i, h)
+		p := point{i, -i}
+		hp := p1.Hash(p)
+		p1.AddHashed(p, i, hp)
+		p2.AddHashed(p, i, hp)
+	}
+	for i := range n {
+		h := c1.Hash(str(i))
+		for _, c := range []*Cache[string, int]{c1, c2} {
+			if v, ok := c.GetHashed(str(i), h); !ok || v != i {
+				t.Fatalf("key %q expect %d true, got %d %v", str(i), i, v, ok)
+			}
+		}
+		if i%2 == 0 {
+			c1.DelHashed(str(i), h)
+		}
+		if v, ok := p2.Get(point{i, -i}); !ok || v != i {
+			t.Fatalf("key %v expect %d true, got %d %v", point{i, -i}, i, v, ok)
+		}
+	}
+	if c1.Len() != n/2 || c2.Len() != n || p2.Len() != n {
+		t.Fatalf("expect len %d, %d and %d, got %d, %d and %d", n/2, n, n, c1.Len(), c2.Len(), p2.Len())
+	}
+}
+
+func TestCacheClearReset(t *testing.T) {
+	const n = 5000
+	for _, opt := range []Option{WithLRU(), WithCLOCK(), WithS3FIFO(), WithHasher[string](StringHasher{})} {
+		c := NewCache[string, int](n, opt)
+		for i := range n {
+			c.Add(str(i), i)
+		}
+		tables := len(c.tables)
+		c.Clear()
+		if c.Len() != 0 || len(c.tables) != tables {
+			t.Fatalf("expect empty with %d tables, got %d and %d", tables, c.Len(), len(c.tables))
+		}
+		for i := range n {
+			if _, ok := c.Get(str(i)); ok {
+				t.Fatalf("key %q found after Clear", str(i))
+			}
+		}
+		// the cache and its policy are usable after Clear and Reset
+		for _, empty := range []func(){c.Clear, c.Reset} {
+			for i := range 2 * n {
+				c.Add(strB(i), i)
+			}
+			for i := range 2 * n {
+				if v, ok := c.Get(strB(i)); ok && v != i {
+					t.Fatalf("key %q expect %d, got %d", strB(i), i, v)
+				}
+			}
+			if c.policy != nil && c.Len() != n {
+				t.Fatalf("expect len %d, got %d", n, c.Len())
+			}
+			empty()
+		}
+		if len(c.tables) != 1 || c.depth != 0 || c.tables[0].depth != 0 {
+			t.Fatalf("expect a single table after Reset, got %d", len(c.tables))
+		}
+	}
+}
+
+func TestCacheShrink(t *testing.T) {
+	const n = 100000
+	const keep = 100
+	for _, test := range []struct {
+		name string
+		hint int // size hint of NewCache
+		opt  Option
+	}{
+		{"hint", n, WithHasher[int](IntHasher[int]{})},
+		{"lru", n, WithLRU()},
+		{"nohint", 0, WithHasher[int](IntHasher[int]{})},
+	} {
+		c := NewCache[int, int](test.hint, test.opt)
+		for i := range n {
+			c.Add(i, i)
+		}
+		tables := len(c.tables)
+		for i := keep; i < n; i++ {
+			c.Del(i)
+		}
+		// Del doesn't merge the tables allocated for the size hint
+		if test.hint > 0 && len(c.tables) != tables {
+			t.Fatalf("%s: expect %d directory entries, got %d", test.name, tables, len(c.tables))
+		}
+		if test.hint == 0 && len(c.tables) >= tables/8 {
+			t.Fatalf("%s: expect less than %d directory entries, got %d", test.name, tables/8, len(c.tables))
+		}
+		c.Compact()
+		if len(c.tables) != 1 || c.depth != 0 || c.tables[0].depth != 0 {
+			t.Fatalf("%s: expect a single table, got %d", test.name, len(c.tables))
+		}
+		for i := range n {
+			if v, ok := c.Get(i); ok != (i < keep) || ok && v != i {
+				t.Fatalf("%s: key %d expect %d %v, got %d %v", test.name, i, i, i < keep, v, ok)
+			}
+		}
+		// the cache grows again after shrinking
+		for i := range n {
+			c.Add(i, i)
+		}
+		if c.Len() != n {
+			t.Fatalf("expect len %d, got %d", n, c.Len())
+		}
+		for i := range n {
+			if v, ok := c.Get(i); !ok || v != i {
+				t.Fatalf("key %d expect %d true, got %d %v", i, i, v, ok)
+			}
+		}
+	}
+}
+
+// TestCacheFloor checks that the directory allocated by the size hint, or
+// kept by Clear, keeps its depth while keys are added and deleted.
+func TestCacheFloor(t *testing.T) {
+	const n = 100000
+	c := NewCache[int, int](n)
+	depth := c.depth
+	load := func(name string) {
+		// one key deleted every 4 additions
+		for i := range n {
+			c.Add(i, i)
+			if i%4 == 3 {
+				c.Del(i - 2)
+			}
+			if c.depth < depth {
+				t.Fatalf("%s: %d expect depth %d, got %d", name, i, depth, c.depth)
+			}
+		}
+	}
+	load("hint")
+	if c.depth != depth {
+		t.Fatalf("expect depth %d after load, got %d", depth, c.depth)
+	}
+	c.Clear()
+	load("clear")
+	c.Reset()
+	if c.depth != 0 || c.floor != 0 {
+		t.Fatalf("expect depth and floor 0 after Reset, got %d and %d", c.depth, c.floor)
+	}
+}
+
+func TestNewCacheSizeHint(t *testing.T) {
+	for _, n := range []int{0, 1000, 10000, 100000, 1000000} {
+		c := NewCache[string, int](n)
+		tables := len(c.tables)
+		for _, tbl := range c.tables {
+			if tbl.depth != c.depth {
+				t.Fatalf("%d: expect table depth %d, got %d", n, c.depth, tbl.depth)
+			}
+		}
+		for i := range n {
+			c.Add(str(i), i)
+		}
+		if len(c.tables) != tables {
+			t.Fatalf("%d: expect %d tables, got %d", n, tables, len(c.tables))
+		}
+		for i := range n {
+			if v, ok := c.Get(str(i)); !ok || v != i {
+				t.Fatalf("%d: key %q expect %d true, got %d %v", n, str(i), i, v, ok)
+			}
+		}
+	}
+}
+
 const fixedSeed1 = 12345
 const fixedSeed2 = 76890
 
 var cacheSizes = []int{1, 10, 100, 1000, 10000, 100000, 1000000, 10000000}
 
+// BenchmarkBulkLoad measures the time to add size keys in an empty cache
+// without and with a size hint, and in a go map made with a size hint.
+func BenchmarkBulkLoad(b *testing.B) {
+	size := cacheSizes[len(cacheSizes)-1]
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	for _, size := range cacheSizes[3:] {
+		b.Run(fmt.Sprintf("init/%8d", size), func(b *testing.B) {
+			for range b.N {
+				var c Cache[string, int]
+				c.Init()
+				for i := range size {
+					c.Add(ss[i], i)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("hint/%8d", size), func(b *testing.B) {
+			for range b.N {
+				c := NewCache[string, int](size)
+				for i := range size {
+					c.Add(ss[i], i)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("gomap/%8d", size), func(b *testing.B) {
+			for range b.N {
+				m := make(map[string]int, size)
+				for i := range size {
+					m[ss[i]] = i
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkCache2Hit(b *testing.B) {
 	size := cacheSizes[len(cacheSizes)-1]
 	ss := make([]string, size)
@@ -107,7 +401,7 @@ func BenchmarkCache2Hit(b *testing.B) {
 		})
 
 		b.Run(fmt.Sprintf("%8d", size), func(b *testing.B) {
-			var c Cache
+			var c Cache[string, int]
 			c.Init()
 			for i := range size {
 				c.Add(ss[i], i)
@@ -139,7 +433,7 @@ func BenchmarkCache2Miss(b *testing.B) {
 		})
 
 		b.Run(fmt.Sprintf("%8d", size), func(b *testing.B) {
-			var c Cache
+			var c Cache[string, int]
 			c.Init()
 			for i := range size {
 				c.Add(ss[i], i)
@@ -0,0 +1,60 @@
+package altmap
+
+// clock is the CLOCK (second chance) eviction policy. The reference bit
+// of slot j of group i of a table is the bit j of refs[i].
+type clock[K comparable, V any] struct {
+	hand // position of the clock hand
+}
+
+func (p *clock[K, V]) added(t *table[K, V], slot int, key K, hash uint) {}
+
+func (p *clock[K, V]) hit(t *table[K, V], slot int) {
+	t.refs[slot/nItems] |= 1 << (slot % nItems)
+}
+
+func (p *clock[K, V]) removed(t *table[K, V], slot int) {
+	t.refs[slot/nItems] &^= 1 << (slot % nItems)
+}
+
+func (p *clock[K, V]) victim(c *Cache[K, V]) K {
+	for {
+		if p.tIdx >= uint(len(c.tables)) {
+			p.tIdx = 0
+		}
+		t := c.tables[p.tIdx]
+		if p.tIdx >= 1<<t.depth {
+			p.next()
+			continue
+		}
+		if slot := clockVictim(&p.hand, t); slot >= 0 {
+			return t.item(slot).key
+		}
+		p.next()
+	}
+}
+
+// clockVictim moves the hand h over the slots of t from its position,
+// clearing the reference bits of the used slots, and returns the first used
+// slot whose bit was clear, or -1 when reaching the end of t.
+func clockVictim[K comparable, V any](h *hand, t *table[K, V]) int {
+	for h.slot < t.cap() {
+		g := h.slot / nItems
+		used := t.groups[g].header.FindUsed().Pack()
+		used &^= PSet(1<<(h.slot%nItems)) - 1 // ignore slots before the hand
+		victims := used &^ PSet(t.refs[g])
+		if !victims.Empty() {
+			i := victims.Pos()
+			// give a second chance to the referenced slots before the victim
+			t.refs[g] &^= byte(used) & (1<<i - 1)
+			h.slot = g*nItems + i + 1
+			return g*nItems + i
+		}
+		t.refs[g] &^= byte(used)
+		h.slot = (g + 1) * nItems
+	}
+	return -1
+}
+
+func (p *clock[K, V]) clear() {
+	p.hand = hand{}
+}
@@ -0,0 +1,90 @@
+package altmap
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestClockSecondChance(t *testing.T) {
+	const maxItems = 1000
+	evicted := map[int]bool{}
+	c := NewCache[int, int](maxItems, WithCLOCK(), WithEvictCallback(func(k, v int) {
+		if k != v {
+			t.Fatalf("evicted key %d with value %d", k, v)
+		}
+		evicted[k] = true
+	}))
+	for i := range maxItems {
+		c.Add(i, i)
+	}
+	// reference the even keys so that only odd keys are evicted
+	for i := 0; i < maxItems; i += 2 {
+		c.Get(i)
+	}
+	for i := maxItems; i < maxItems+maxItems/2; i++ {
+		c.Add(i, i)
+	}
+	if exp, got := maxItems, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	if exp, got := maxItems/2, len(evicted); exp != got {
+		t.Fatalf("expect %d evicted items, got %d", exp, got)
+	}
+	for k := range evicted {
+		if k < maxItems && k%2 == 0 {
+			t.Fatalf("referenced key %d was evicted", k)
+		}
+	}
+}
+
+// TestClockRandom checks that the cache content matches the added keys minus
+// the deleted and evicted keys on a random workload large enough to split and
+// rehash tables.
+func TestClockRandom(t *testing.T) {
+	const maxItems = 5000
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	m := map[int]int{}
+	c := NewCache[int, int](maxItems, WithCLOCK(), WithEvictCallback(func(k, v int) {
+		if exp, ok := m[k]; !ok || exp != v {
+			t.Fatalf("evicted key %d value %d, expect %d %v", k, v, exp, ok)
+		}
+		delete(m, k)
+	}))
+	for i := range 100000 {
+		k := rng.IntN(3 * maxItems)
+		switch rng.IntN(10) {
+		case 0:
+			c.Del(k)
+			delete(m, k)
+		case 1, 2, 3:
+			v, ok := c.Get(k)
+			if exp, found := m[k]; found != ok || exp != v {
+				t.Fatalf("%d get %d expect %d %v, got %d %v", i, k, exp, found, v, ok)
+			}
+		default:
+			c.Add(k, i)
+			m[k] = i
+		}
+		if c.Len() != len(m) || c.Len() > maxItems {
+			t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+		}
+	}
+}
+
+func BenchmarkClockHit(b *testing.B) {
+	size := cacheSizes[5]
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	c := NewCache[string, int](size, WithCLOCK())
+	for i := range size {
+		c.Add(ss[i], i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, found := c.Get(ss[i%size]); !found {
+			b.Fatalf("Key %s should be found", ss[i%size])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package altmap
+
+// Compute calls fn with the value associated to key and true if it is found,
+// or the zero value and false otherwise. If fn returns keep true, the key is
+// associated to the new value returned by fn, otherwise the key is deleted.
+// Compute returns the new value and keep. The key is hashed once and the
+// slot of the key, or where to add it, is found by a single probe sequence.
+// fn must not modify the cache.
+func (c *Cache[K, V]) Compute(key K, fn func(old V, found bool) (new V, keep bool)) (value V, ok bool) {
+	hash := c.hasher.Hash(c.seed, key)
+	t := c.table(hash)
+	var slot int
+	var found, fast bool
+	if fast = c.fast(t); fast {
+		slot, found = t.probe(key, hash)
+	} else {
+		t, slot = c.lookup(t, key, hash)
+		found = slot >= 0
+	}
+	var old V
+	if found {
+		old = t.item(slot).value
+	}
+	value, ok = fn(old, found)
+	switch {
+	case found && ok:
+		t.item(slot).value = value
+		if c.policy != nil {
+			c.policy.hit(t, slot)
+		}
+	case found:
+		c.delAt(t, slot, hash)
+	case ok && fast:
+		c.insertAt(t, slot, key, value, hash)
+	case ok:
+		c.addNew(t, key, value, hash)
+	}
+	return
+}
+
+// GetOrAdd returns the value associated to key and true if it is found,
+// otherwise it adds the key and value and returns value and false. The key
+// is hashed once and found or added by a single probe sequence.
+func (c *Cache[K, V]) GetOrAdd(key K, value V) (actual V, found bool) {
+	t, slot, found := c.upsert(key, c.hasher.Hash(c.seed, key))
+	item := t.item(slot)
+	if !found {
+		item.value = value
+	}
+	return item.value, found
+}
+
+// AddIfAbsent adds the key and value and returns true if the key is not in
+// the cache, otherwise it leaves the value of the key unchanged and returns
+// false.
+func (c *Cache[K, V]) AddIfAbsent(key K, value V) bool {
+	_, found := c.GetOrAdd(key, value)
+	return !found
+}
@@ -0,0 +1,135 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// TestCompute checks Compute, GetOrAdd and AddIfAbsent against a go map.
+func TestCompute(t *testing.T) {
+	for name, opts := range upsertOptions {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+			c := NewCache[string, int](20000, opts...)
+			m := map[string]int{}
+			for i := range 100000 {
+				k := str(rng.IntN(20000))
+				v := rng.IntN(100)
+				exp, expFound := m[k]
+				switch rng.IntN(4) {
+				case 0:
+					got, found := c.GetOrAdd(k, v)
+					if found != expFound || found && got != exp || !found && got != v {
+						t.Fatalf("%d GetOrAdd(%q, %d) expect %d %v, got %d %v", i, k, v, exp, expFound, got, found)
+					}
+					if !found {
+						m[k] = v
+					}
+				case 1:
+					if added := c.AddIfAbsent(k, v); added == expFound {
+						t.Fatalf("%d AddIfAbsent(%q, %d) expect %v, got %v", i, k, v, !expFound, added)
+					}
+					if !expFound {
+						m[k] = v
+					}
+				default:
+					// keep odd sums and delete even ones
+					got, keep := c.Compute(k, func(old int, found bool) (int, bool) {
+						if found != expFound || old != exp {
+							t.Fatalf("%d Compute(%q) expect %d %v, got %d %v", i, k, exp, expFound, old, found)
+						}
+						return old + v, (old+v)%2 == 1
+					})
+					if got != exp+v || keep != ((exp+v)%2 == 1) {
+						t.Fatalf("%d Compute(%q) expect %d, got %d %v", i, k, exp+v, got, keep)
+					}
+					if keep {
+						m[k] = got
+					} else {
+						delete(m, k)
+					}
+				}
+				if c.Len() != len(m) {
+					t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+				}
+			}
+			for k, v := range m {
+				if got, ok := c.Get(k); !ok || got != v {
+					t.Fatalf("key %q expect %d, got %d %v", k, v, got, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeTTL(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[string, int](0, WithClock(clk.now))
+	c.AddWithTTL("a", 5, time.Second)
+	c.AddWithTTL("b", 5, time.Second)
+	clk.t = clk.t.Add(time.Second)
+	c.Compute("a", func(old int, found bool) (int, bool) {
+		if found {
+			t.Fatalf("expect expired key not found, got %d", old)
+		}
+		return 1, true
+	})
+	if added := c.AddIfAbsent("b", 2); !added {
+		t.Fatalf("expect expired key added")
+	}
+	if v, _ := c.Get("a"); v != 1 {
+		t.Fatalf("expect 1, got %d", v)
+	}
+	if v, _ := c.Get("b"); v != 2 {
+		t.Fatalf("expect 2, got %d", v)
+	}
+}
+
+// TestGetOrAddLRU checks that a found key is a hit for the eviction policy.
+func TestGetOrAddLRU(t *testing.T) {
+	c := NewCache[int, int](2, WithLRU())
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.GetOrAdd(1, 10)
+	c.Add(3, 3)
+	if _, ok := c.Get(2); ok {
+		t.Fatalf("expect key 2 evicted")
+	}
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("expect key 1 with 1, got %d %v", v, ok)
+	}
+}
+
+// BenchmarkCompute measures a read-modify-write of size keys with Compute
+// and with Get then Add.
+func BenchmarkCompute(b *testing.B) {
+	const n = 1 << 20
+	for _, size := range cacheSizes[3:6] {
+		keys := make([]string, n)
+		rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+		for i := range keys {
+			keys[i] = str(rng.IntN(size))
+		}
+		incr := func(old int, _ bool) (int, bool) {
+			return old + 1, true
+		}
+		b.Run(fmt.Sprintf("compute/%8d", size), func(b *testing.B) {
+			var c Cache[string, int]
+			c.Init()
+			for i := range b.N {
+				c.Compute(keys[i&(n-1)], incr)
+			}
+		})
+		b.Run(fmt.Sprintf("getadd/%8d", size), func(b *testing.B) {
+			var c Cache[string, int]
+			c.Init()
+			for i := range b.N {
+				k := keys[i&(n-1)]
+				v, _ := c.Get(k)
+				c.Add(k, v+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package altmap
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+/*
+ConcurrentCache readers don't take locks. Each table has a sequence counter
+that writers make odd while they modify a value in place, and readers retry
+a lookup when the counter was odd or changed during the lookup. A torn
+value is thus never returned.
+
+Comparing a torn key could dereference an invalid pointer, so keys are never
+modified while readers may see them. A slot is written before its top hash
+is stored in the header with an atomic store, a deleted item is kept in its
+slot marked by a tombstone, and tombstones are not reused. The tombstones
+are reclaimed by a rehash that builds a new table.
+
+Split and rehash never modify the table. They build new tables and publish
+a modified copy of the directory. The replaced table is marked retired so
+that the writers waiting for its lock retry with the new directory, while
+readers still using it see a consistent snapshot.
+*/
+
+// ctable is a table of a ConcurrentCache with the state synchronizing its
+// readers and writers.
+type ctable[K comparable, V any] struct {
+	*table[K, V]
+	mu      sync.Mutex    // serializes the writers
+	seq     atomic.Uint32 // odd while a writer modifies the table
+	retired bool          // true when replaced in the directory, guarded by mu
+}
+
+// directory is an immutable directory of tables.
+type directory[K comparable, V any] struct {
+	tables []*ctable[K, V]
+	depth  byte // depth of the directory
+}
+
+// table returns the table of the given hash.
+func (d *directory[K, V]) table(hash uint) *ctable[K, V] {
+	return d.tables[H0(hash)&uint(len(d.tables)-1)]
+}
+
+// ConcurrentCache is a Cache safe for concurrent use where Get doesn't take
+// any lock. Writers take a per table lock.
+//
+// A Get of a key whose value is concurrently updated by Add may read the
+// value while it is written, and then retries the lookup. The torn value is
+// never returned, but the read is a data race for the Go memory model: a
+// program reading and updating the same keys concurrently reports races
+// when run with -race. Reading keys that are not updated concurrently,
+// while other keys are added and deleted, is race free.
+type ConcurrentCache[K comparable, V any] struct {
+	dir    atomic.Pointer[directory[K, V]]
+	mu     sync.Mutex // serializes the directory updates
+	seed   Seed       // hash seed
+	hasher Hasher[K]  // hasher of keys
+	nItems atomic.Int64
+}
+
+// NewConcurrentCache returns a new concurrent cache. Only the WithHasher and
+// WithSeed options are supported. It panics with other options, or if the
+// key type of the hasher doesn't match K.
+func NewConcurrentCache[K comparable, V any](options ...Option) *ConcurrentCache[K, V] {
+	cfg := newConfig(options)
+	if cfg.policy != noPolicy || cfg.onEvict != nil || cfg.now != nil || cfg.incremental ||
+		newGeometry(cfg.sizeLog2, cfg.maxLoad, cfg.maxTombstones) != nil {
+		panic("altmap: ConcurrentCache only supports the WithHasher and WithSeed options")
+	}
+	c := &ConcurrentCache[K, V]{
+		seed:   MakeSeed(),
+		hasher: configHasher[K](cfg),
+	}
+	if cfg.seed != nil {
+		c.seed = *cfg.seed
+	}
+	c.dir.Store(&directory[K, V]{tables: []*ctable[K, V]{{table: newTable[K, V](0)}}})
+	return c
+}
+
+// Len returns the number of items stored in the cache.
+func (c *ConcurrentCache[K, V]) Len() int {
+	return int(c.nItems.Load())
+}
+
+// loadHeader returns the header of g with an atomic load.
+func loadHeader(g *Hdr) Hdr {
+	return Hdr(atomic.LoadUintptr((*uintptr)(unsafe.Pointer(g))))
+}
+
+// storeHeader sets the header g to h with an atomic store.
+func storeHeader(g *Hdr, h Hdr) {
+	atomic.StoreUintptr((*uintptr)(unsafe.Pointer(g)), uintptr(h))
+}
+
+// Get returns the value associated to key and true if it is found.
+func (c *ConcurrentCache[K, V]) Get(key K) (value V, ok bool) {
+	hash := c.hasher.Hash(c.seed, key)
+	for {
+		t := c.dir.Load().table(hash)
+		seq := t.seq.Load()
+		if seq&1 == 0 {
+			value, ok = t.load(key, hash)
+			if t.seq.Load() == seq {
+				return value, ok
+			}
+		}
+		runtime.Gosched()
+	}
+}
+
+// load is get using atomic loads of the headers. The result is valid only if
+// the table sequence counter didn't change.
+func (t *table[K, V]) load(key K, hash uint) (value V, ok bool) {
+	groups := t.array()
+	pattern := MakePattern(H2(hash))
+	idx := makeIndex(H1(hash))
+	// the probing is bounded as a concurrent writer may fill the free slots
+	for pos := range uint(tableSize) {
+		g := &groups[idx]
+		h := loadHeader(&g.header)
+		for set := h.Find(pattern); !set.Empty(); set = set.Next() {
+			if item := &g.item[set.Pos()&(nItems-1)]; item.key == key {
+				return item.value, true
+			}
+		}
+		if h.HasFreeSlots() {
+			return
+		}
+		// the mask avoids a modulo and a bound check
+		idx = (idx + pos + 1) & (tableSize - 1)
+	}
+	return
+}
+
+// lock returns the locked table of hash. The table is not retired.
+func (c *ConcurrentCache[K, V]) lock(hash uint) *ctable[K, V] {
+	for {
+		t := c.dir.Load().table(hash)
+		t.mu.Lock()
+		if !t.retired {
+			return t
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Add swaps the value and return true if the key is found in the cache,
+// otherwise it adds the key and value and returns false.
+func (c *ConcurrentCache[K, V]) Add(key K, value V) (oldValue V, ok bool) {
+	hash := c.hasher.Hash(c.seed, key)
+	for {
+		t := c.lock(hash)
+		if slot := t.find(key, hash); slot >= 0 {
+			t.seq.Add(1)
+			item := t.item(slot)
+			oldValue, item.value = item.value, value
+			t.seq.Add(1)
+			t.mu.Unlock()
+			return oldValue, true
+		}
+		if t.storeFree(key, value, hash) {
+			t.mu.Unlock()
+			c.nItems.Add(1)
+			return
+		}
+		// the table is full, it must be split
+		t1, t2 := t.split(1<<t.depth, c.seed, c.hasher)
+		c.replace(t, hash, t1, t2)
+		t.mu.Unlock()
+	}
+}
+
+// storeFree adds the key and value in the first free slot, ignoring the
+// tombstones, and publishes it with an atomic store of the header. Requires
+// the key is not in the table and t is locked. Returns false if the table
+// is full.
+func (t *table[K, V]) storeFree(key K, value V, hash uint) bool {
+	if int(t.nItems)+int(t.nTombstones) > maxUsed {
+		return false
+	}
+	groups := t.array()
+	var pos uint
+	idx := makeIndex(H1(hash))
+	for {
+		g := &groups[idx]
+		if set := g.header.findZeros(); !set.Empty() {
+			i := set.Pos() & (nItems - 1)
+			g.item[i] = Item[K, V]{key: key, value: value}
+			storeHeader(&g.header, g.header.Set(i, H2(hash)))
+			t.nItems++
+			return true
+		}
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
+	}
+}
+
+// Del deletes key from the cache.
+func (c *ConcurrentCache[K, V]) Del(key K) {
+	hash := c.hasher.Hash(c.seed, key)
+	t := c.lock(hash)
+	defer t.mu.Unlock()
+	slot := t.find(key, hash)
+	if slot < 0 {
+		return
+	}
+	// the item is kept as readers may be comparing its key
+	g := &t.groups[slot/nItems]
+	storeHeader(&g.header, g.header.Set(slot%nItems, tombstone))
+	t.nTombstones++
+	t.nItems--
+	c.nItems.Add(-1)
+	if int(t.nTombstones) > maxTombstones {
+		c.replace(t, hash, t.rehash(c.seed, c.hasher), nil)
+	}
+}
+
+// replace replaces the locked table t of hash in the directory by t1 if t2 is
+// nil, or by the tables t1 and t2 resulting of its split, and marks t retired.
+func (c *ConcurrentCache[K, V]) replace(t *ctable[K, V], hash uint, t1, t2 *table[K, V]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d := c.dir.Load()
+	l := uint(len(d.tables))
+	d2 := &directory[K, V]{depth: d.depth}
+	if t2 != nil && t.depth == d.depth {
+		// grow the directory
+		d2.tables = make([]*ctable[K, V], 2*l)
+		copy(d2.tables[l:], d.tables)
+		d2.depth++
+	} else {
+		d2.tables = make([]*ctable[K, V], l)
+	}
+	copy(d2.tables, d.tables)
+
+	ct1, ct2 := &ctable[K, V]{table: t1}, &ctable[K, V]{table: t2}
+	step := uint(1 << t.depth) // interval between pointers to the table
+	tIdx := H0(hash) & (step - 1)
+	for l = uint(len(d2.tables)); tIdx < l; tIdx += step {
+		d2.tables[tIdx] = ct1
+		if t2 != nil {
+			tIdx += step
+			d2.tables[tIdx] = ct2
+		}
+	}
+	c.dir.Store(d2)
+	t.retired = true
+}
@@ -0,0 +1,57 @@
+//go:build !race
+
+// The readers of a ConcurrentCache may read a value while it is modified and
+// then retry the lookup, which the race detector reports as a data race.
+
+package altmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentCacheReaders(t *testing.T) {
+	const nKeys = 50000
+	const nReaders = 4
+	c := NewConcurrentCache[int, [2]int]()
+	for i := 0; i < nKeys; i += 2 {
+		c.Add(i, [2]int{10 * i, 10 * i})
+	}
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for r := range nReaders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := r; !done.Load(); i = (i + 7) % nKeys {
+				// a value must never be torn and stable keys must be found
+				v, ok := c.Get(i)
+				if ok && (v[0]/10 != i || v[1] != v[0]) {
+					t.Errorf("key %d got torn or invalid value %v", i, v)
+					return
+				}
+				if !ok && i%4 == 0 {
+					t.Errorf("key %d should be found", i)
+					return
+				}
+			}
+		}()
+	}
+
+	// the keys multiple of 4 are only updated, the others are added and
+	// deleted to split and rehash the tables
+	for round := range 4 {
+		for i := range nKeys {
+			switch {
+			case i%4 == 0, (i+round)%2 == 0:
+				c.Add(i, [2]int{10*i + round, 10*i + round})
+			default:
+				c.Del(i)
+			}
+		}
+	}
+	done.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,111 @@
+package altmap
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentCacheAddDel(t *testing.T) {
+	c := NewConcurrentCache[string, int]()
+	ss := []string{}
+	for i := range 20000 {
+		if _, ok := c.Add(str(i), i); ok {
+			t.Fatalf("%d expect key %q to be new", i, str(i))
+		}
+		ss = append(ss, str(i))
+	}
+	for i, s := range ss {
+		if v, ok := c.Get(s); !ok || v != i {
+			t.Fatalf("key %q expect %d true, got %d %v", s, i, v, ok)
+		}
+		if old, ok := c.Add(s, -i); !ok || old != i {
+			t.Fatalf("key %q expect %d true, got %d %v", s, i, old, ok)
+		}
+	}
+	rand.Shuffle(len(ss), func(i, j int) {
+		ss[i], ss[j] = ss[j], ss[i]
+	})
+	for len(ss) > 0 {
+		key := ss[len(ss)-1]
+		ss = ss[:len(ss)-1]
+		c.Del(key)
+		if _, ok := c.Get(key); ok {
+			t.Fatalf("key %q should be deleted", key)
+		}
+		if len(ss)%1000 == 0 {
+			for _, key := range ss {
+				if _, ok := c.Get(key); !ok {
+					t.Fatalf("failed to find key %q", key)
+				}
+			}
+		}
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expect empty, got %d", c.Len())
+	}
+}
+
+// TestConcurrentCacheDisjoint checks the readers of keys that are not
+// modified while writers add and delete other keys, splitting and rehashing
+// the tables. It runs with the race detector, that reports no race as the
+// readers and the writers don't access the same keys.
+func TestConcurrentCacheDisjoint(t *testing.T) {
+	const nKeys = 20000
+	const nReaders = 2
+	const nWriters = 2
+	c := NewConcurrentCache[int, int]()
+	// the readers read the multiples of 4, the writers the other keys
+	for i := 0; i < nKeys; i += 4 {
+		c.Add(i, -i)
+	}
+
+	var done atomic.Bool
+	var readers, writers sync.WaitGroup
+	for r := range nReaders {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for i := 4 * r; !done.Load(); i = (i + 4*7) % nKeys {
+				if v, ok := c.Get(i); !ok || v != -i {
+					t.Errorf("key %d expect %d true, got %d %v", i, -i, v, ok)
+					return
+				}
+			}
+		}()
+	}
+	for w := range nWriters {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for round := range 4 {
+				for i := range nKeys {
+					if i%4 == 0 || i%nWriters != w {
+						continue
+					}
+					if (i+round)%3 == 0 {
+						c.Del(i)
+					} else {
+						c.Add(i, i)
+					}
+				}
+			}
+		}()
+	}
+	writers.Wait()
+	done.Store(true)
+	readers.Wait()
+	if len(c.dir.Load().tables) == 1 {
+		t.Fatalf("expect the table to be split")
+	}
+	// the last round deleted the multiples of 3 and added the other keys
+	for i := range nKeys {
+		if i%4 == 0 {
+			continue
+		}
+		if v, ok := c.Get(i); ok != (i%3 != 0) || ok && v != i {
+			t.Fatalf("key %d expect %d %v, got %d %v", i, i, i%3 != 0, v, ok)
+		}
+	}
+}
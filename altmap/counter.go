@@ -0,0 +1,124 @@
+package altmap
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// Inc adds delta to the value associated to key in c, adding the key with
+// the value delta if it is not found, and returns the new value. The key is
+// looked up and inserted by a single probe sequence.
+func Inc[K comparable](c *Cache[K, int], key K, delta int) int {
+	t, slot, _ := c.upsert(key, c.hasher.Hash(c.seed, key))
+	item := t.item(slot)
+	item.value += delta
+	return item.value
+}
+
+// Counter is a map of keys to integer counts stored in the tables of a Cache.
+// A key that is not in the counter has a count of zero.
+type Counter[K comparable] struct {
+	c Cache[K, int]
+}
+
+// Count is a key and its count returned by Counter.TopK.
+type Count[K comparable] struct {
+	Key K
+	N   int
+}
+
+// NewCounter returns a new counter with the directory and tables allocated
+// up front for sizeHint keys. The eviction policy options are not supported.
+func NewCounter[K comparable](sizeHint int, options ...Option) *Counter[K] {
+	if newConfig(options).policy != noPolicy {
+		panic("altmap: Counter doesn't support eviction policies")
+	}
+	return &Counter[K]{c: *NewCache[K, int](sizeHint, options...)}
+}
+
+// Init initializes an empty counter with the default hasher for K.
+func (c *Counter[K]) Init() {
+	c.c.Init()
+}
+
+// Len returns the number of keys in the counter.
+func (c *Counter[K]) Len() int {
+	return c.c.Len()
+}
+
+// Inc adds delta to the count of key and returns the new count. A count
+// reaching zero is kept in the counter until the key is deleted.
+func (c *Counter[K]) Inc(key K, delta int) int {
+	return Inc(&c.c, key, delta)
+}
+
+// Get returns the count of key.
+func (c *Counter[K]) Get(key K) int {
+	n, _ := c.c.Get(key)
+	return n
+}
+
+// Del deletes key from the counter.
+func (c *Counter[K]) Del(key K) {
+	c.c.Del(key)
+}
+
+// All returns an iterator over the keys and counts with the same semantic
+// as Cache.All.
+func (c *Counter[K]) All() iter.Seq2[K, int] {
+	return c.c.All()
+}
+
+// TopK returns the n keys with the largest counts ordered by decreasing
+// count. The order of keys with the same count is unspecified. The counts
+// are scanned once keeping the n largest in a min-heap.
+func (c *Counter[K]) TopK(n int) []Count[K] {
+	if n <= 0 {
+		return nil
+	}
+	h := make([]Count[K], 0, min(n, c.Len()))
+	for k, v := range c.c.All() {
+		if len(h) < n {
+			h = append(h, Count[K]{k, v})
+			siftUp(h, len(h)-1)
+		} else if v > h[0].N {
+			h[0] = Count[K]{k, v}
+			siftDown(h, 0)
+		}
+	}
+	slices.SortFunc(h, func(a, b Count[K]) int {
+		return cmp.Compare(b.N, a.N)
+	})
+	return h
+}
+
+// siftUp moves up the element i of the min-heap h.
+func siftUp[K comparable](h []Count[K], i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if h[p].N <= h[i].N {
+			return
+		}
+		h[p], h[i] = h[i], h[p]
+		i = p
+	}
+}
+
+// siftDown moves down the element i of the min-heap h.
+func siftDown[K comparable](h []Count[K], i int) {
+	for {
+		m := i
+		if l := 2*i + 1; l < len(h) && h[l].N < h[m].N {
+			m = l
+		}
+		if r := 2*i + 2; r < len(h) && h[r].N < h[m].N {
+			m = r
+		}
+		if m == i {
+			return
+		}
+		h[m], h[i] = h[i], h[m]
+		i = m
+	}
+}
@@ -0,0 +1,136 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// upsertOptions are the cache options changing the path of the single probe
+// insertion. The LRU caches are sized for the tests to not evict.
+var upsertOptions = map[string][]Option{
+	"default":     nil,
+	"geometry":    {WithTableSizeLog2(4)},
+	"incremental": {WithIncrementalSplit()},
+	"lru":         {WithLRU()},
+}
+
+// TestInc checks the values of Inc against a go map.
+func TestInc(t *testing.T) {
+	for name, opts := range upsertOptions {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+			c := NewCache[string, int](20000, opts...)
+			m := map[string]int{}
+			for i := range 100000 {
+				k := str(rng.IntN(20000))
+				if rng.IntN(8) == 0 {
+					c.Del(k)
+					delete(m, k)
+					continue
+				}
+				d := rng.IntN(10) - 3
+				m[k] += d
+				if got := Inc(c, k, d); got != m[k] {
+					t.Fatalf("%d Inc(%q, %d) expect %d, got %d", i, k, d, m[k], got)
+				}
+				if c.Len() != len(m) {
+					t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+				}
+			}
+			for k, v := range m {
+				if got, ok := c.Get(k); !ok || got != v {
+					t.Fatalf("key %q expect %d, got %d %v", k, v, got, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestIncTTL(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[string, int](0, WithClock(clk.now))
+	c.AddWithTTL("a", 5, time.Second)
+	if got := Inc(c, "a", 1); got != 6 {
+		t.Fatalf("expect 6, got %d", got)
+	}
+	clk.t = clk.t.Add(time.Second)
+	if got := Inc(c, "a", 1); got != 1 {
+		t.Fatalf("expect expired key counted from 0, got %d", got)
+	}
+}
+
+func TestCounterTopK(t *testing.T) {
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	var c Counter[int]
+	c.Init()
+	m := map[int]int{}
+	for range 50000 {
+		k := int(rng.ExpFloat64() * 100)
+		c.Inc(k, 1)
+		m[k]++
+	}
+	if c.Len() != len(m) {
+		t.Fatalf("expect len %d, got %d", len(m), c.Len())
+	}
+	counts := slices.Sorted(func(yield func(int) bool) {
+		for _, n := range m {
+			if !yield(n) {
+				return
+			}
+		}
+	})
+	slices.Reverse(counts)
+	for _, n := range []int{0, 1, 10, len(m), len(m) + 10} {
+		top := c.TopK(n)
+		if exp := min(n, len(m)); len(top) != exp {
+			t.Fatalf("TopK(%d) expect %d counts, got %d", n, exp, len(top))
+		}
+		for i, kn := range top {
+			if kn.N != counts[i] || m[kn.Key] != kn.N {
+				t.Fatalf("TopK(%d)[%d] expect count %d, got %d for key %d with count %d", n, i, counts[i], kn.N, kn.Key, m[kn.Key])
+			}
+		}
+	}
+	c.Del(0)
+	if c.Get(0) != 0 {
+		t.Fatalf("expect deleted key count 0, got %d", c.Get(0))
+	}
+}
+
+// BenchmarkCounterInc measures counting size keys with Inc, with Get then
+// Add, and with a go map.
+func BenchmarkCounterInc(b *testing.B) {
+	const n = 1 << 20
+	for _, size := range cacheSizes[3:6] {
+		keys := make([]string, n)
+		rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+		for i := range keys {
+			keys[i] = str(rng.IntN(size))
+		}
+		b.Run(fmt.Sprintf("inc/%8d", size), func(b *testing.B) {
+			var c Cache[string, int]
+			c.Init()
+			for i := range b.N {
+				Inc(&c, keys[i&(n-1)], 1)
+			}
+		})
+		b.Run(fmt.Sprintf("getadd/%8d", size), func(b *testing.B) {
+			var c Cache[string, int]
+			c.Init()
+			for i := range b.N {
+				k := keys[i&(n-1)]
+				v, _ := c.Get(k)
+				c.Add(k, v+1)
+			}
+		})
+		b.Run(fmt.Sprintf("gomap/%8d", size), func(b *testing.B) {
+			m := map[string]int{}
+			for i := range b.N {
+				m[keys[i&(n-1)]]++
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+//go:build altmap_debug
+
+package altmap
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// maxRetired is the number of tables replaced in the directory whose memory
+// is checked for writes through stale pointers in debug builds.
+const maxRetired = 16
+
+// debugState holds the copies of the groups of the last tables replaced in
+// the directory of a Cache. It is empty without the altmap_debug build tag.
+type debugState[K comparable, V any] struct {
+	retired []retiredTable[K, V] // oldest first
+}
+
+// retiredTable is a table replaced in the directory and the copy of the
+// memory of its groups when it was replaced.
+type retiredTable[K comparable, V any] struct {
+	t   *table[K, V]
+	mem []byte
+}
+
+// groupBytes returns the memory of the groups of t.
+func groupBytes[K comparable, V any](t *table[K, V]) []byte {
+	if len(t.groups) == 0 {
+		return nil
+	}
+	size := len(t.groups) * int(unsafe.Sizeof(t.groups[0]))
+	return unsafe.Slice((*byte)(unsafe.Pointer(&t.groups[0])), size)
+}
+
+// retire records the memory of table t that is replaced in the directory and
+// is never modified afterwards by the cache. The table is kept alive until
+// maxRetired more tables are retired.
+func (c *Cache[K, V]) retire(t *table[K, V]) {
+	c.checkStale()
+	d := &c.debug
+	if len(d.retired) == maxRetired {
+		d.retired[0] = retiredTable[K, V]{}
+		d.retired = d.retired[1:]
+	}
+	d.retired = append(d.retired, retiredTable[K, V]{t, bytes.Clone(groupBytes(t))})
+}
+
+// checkStale panics if the memory of a retired table was modified, that is
+// if a value was written through a pointer returned by Ptr or PtrOrInsert
+// after its table was replaced.
+func (c *Cache[K, V]) checkStale() {
+	for _, r := range c.debug.retired {
+		if !bytes.Equal(groupBytes(r.t), r.mem) {
+			panic("altmap: value modified through a stale pointer")
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build altmap_debug
+
+package altmap
+
+import "testing"
+
+// TestStalePtr checks that a write through a pointer to a table replaced by
+// a split panics at the next Ptr.
+func TestStalePtr(t *testing.T) {
+	var c Cache[int, int]
+	c.Init()
+	p, _ := c.PtrOrInsert(0)
+	for i := 1; c.depth == 0; i++ {
+		c.Add(i, i)
+	}
+	*p = 1
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect panic")
+		}
+	}()
+	c.Ptr(0)
+}
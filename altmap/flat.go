@@ -0,0 +1,131 @@
+package altmap
+
+import "fmt"
+
+// FlatCache is a cache of a fixed maximum number of items stored in a single
+// table sized at construction. It has no directory, so that a lookup doesn't
+// select a table, and a new key evicts an item with the CLOCK policy instead
+// of splitting the table when the cache is full.
+type FlatCache[K comparable, V any] struct {
+	t        *table[K, V] // table of all the items
+	seed     Seed         // hash seed
+	hasher   Hasher[K]    // hasher of keys
+	maxItems int          // maximum number of items
+	hand     hand         // position of the clock hand
+	onEvict  func(K, V)   // eviction callback, may be nil
+}
+
+// NewFlatCache returns a new flat cache holding at most maxItems items. The
+// number of groups of its table is the smallest power of two holding maxItems
+// items with the maximum load. Only the WithHasher, WithSeed,
+// WithEvictCallback, WithMaxLoad, WithMaxTombstones and WithCLOCK options are
+// supported. It panics if maxItems is not positive, or if the type of a
+// hasher or callback doesn't match K and V.
+func NewFlatCache[K comparable, V any](maxItems int, options ...Option) *FlatCache[K, V] {
+	cfg := newConfig(options)
+	if (cfg.policy != noPolicy && cfg.policy != clockPolicy) || cfg.now != nil || cfg.sizeLog2 != tableSizeLog2 || cfg.incremental {
+		panic("altmap: FlatCache doesn't support the eviction policy, clock, table size and incremental split options")
+	}
+	if maxItems <= 0 {
+		panic("altmap: maxItems must be positive")
+	}
+	var sizeLog2 uint
+	for int(float64(nItems<<sizeLog2)*cfg.maxLoad) < maxItems {
+		sizeLog2++
+	}
+	geo := makeGeometry(sizeLog2, cfg.maxLoad, cfg.maxTombstones)
+	c := &FlatCache[K, V]{
+		t:        newGeometryTable[K, V](geo),
+		seed:     MakeSeed(),
+		hasher:   configHasher[K](cfg),
+		maxItems: maxItems,
+	}
+	c.t.refs = make([]byte, len(c.t.groups))
+	if cfg.seed != nil {
+		c.seed = *cfg.seed
+	}
+	if cfg.onEvict != nil {
+		fn, ok := cfg.onEvict.(func(K, V))
+		if !ok {
+			panic(fmt.Sprintf("altmap: evict callback %T doesn't match func(%T, %T)", cfg.onEvict, *new(K), *new(V)))
+		}
+		c.onEvict = fn
+	}
+	return c
+}
+
+// Len returns the number of items stored in the cache.
+func (c *FlatCache[K, V]) Len() int {
+	return c.t.len()
+}
+
+// Cap returns the number of item slots in the cache.
+func (c *FlatCache[K, V]) Cap() int {
+	return c.t.cap()
+}
+
+// Get returns the value associated to key and true if it is found.
+func (c *FlatCache[K, V]) Get(key K) (value V, ok bool) {
+	t := c.t
+	slot := t.findGeo(key, c.hasher.Hash(c.seed, key))
+	if slot < 0 {
+		return
+	}
+	t.refs[slot/nItems] |= 1 << (slot % nItems)
+	return t.item(slot).value, true
+}
+
+// Add swaps the value and return true if the key is found in the cache,
+// otherwise it adds the key and value and returns false. When the cache
+// is full, the item selected by the CLOCK policy is evicted.
+func (c *FlatCache[K, V]) Add(key K, value V) (oldValue V, ok bool) {
+	hash := c.hasher.Hash(c.seed, key)
+	if slot := c.t.findGeo(key, hash); slot >= 0 {
+		c.t.refs[slot/nItems] |= 1 << (slot % nItems)
+		item := c.t.item(slot)
+		oldValue, item.value = item.value, value
+		return oldValue, true
+	}
+	if c.t.len() >= c.maxItems {
+		c.evict()
+	}
+	if c.t.insert(key, value, hash) < 0 {
+		// the slots are used by tombstones
+		c.t = c.t.rehash(c.seed, c.hasher)
+		c.t.insert(key, value, hash)
+	}
+	return
+}
+
+// evict deletes the item selected by the CLOCK policy and calls the eviction
+// callback.
+func (c *FlatCache[K, V]) evict() {
+	slot := clockVictim(&c.hand, c.t)
+	for slot < 0 {
+		c.hand = hand{}
+		slot = clockVictim(&c.hand, c.t)
+	}
+	key := c.t.item(slot).key
+	value := c.delSlot(slot)
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+// Del deletes key from the cache.
+func (c *FlatCache[K, V]) Del(key K) {
+	if slot := c.t.findGeo(key, c.hasher.Hash(c.seed, key)); slot >= 0 {
+		c.delSlot(slot)
+	}
+}
+
+// delSlot deletes the item in the given used slot and returns its value. The
+// table is rehashed when the number of tombstones exceeds the threshold.
+func (c *FlatCache[K, V]) delSlot(slot int) V {
+	c.t.refs[slot/nItems] &^= 1 << (slot % nItems)
+	value, rehash := c.t.delSlot(slot)
+	if rehash {
+		c.t = c.t.rehash(c.seed, c.hasher)
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestFlatCacheRandom checks that the cache content matches the added keys
+// minus the deleted and evicted keys on a random workload large enough to
+// evict items and rehash the table.
+func TestFlatCacheRandom(t *testing.T) {
+	for _, maxItems := range []int{1, 7, 1000, 5000} {
+		rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+		m := map[int]int{}
+		c := NewFlatCache[int, int](maxItems, WithEvictCallback(func(k, v int) {
+			if exp, ok := m[k]; !ok || exp != v {
+				t.Fatalf("evicted key %d value %d, expect %d %v", k, v, exp, ok)
+			}
+			delete(m, k)
+		}))
+		if c.Cap() > 4*maxItems+nItems {
+			t.Fatalf("expect capacity at most %d, got %d", 4*maxItems+nItems, c.Cap())
+		}
+		for i := range 100000 {
+			k := rng.IntN(3 * maxItems)
+			switch rng.IntN(10) {
+			case 0:
+				c.Del(k)
+				delete(m, k)
+			case 1, 2, 3:
+				v, ok := c.Get(k)
+				if exp, found := m[k]; found != ok || exp != v {
+					t.Fatalf("%d get %d expect %d %v, got %d %v", i, k, exp, found, v, ok)
+				}
+			default:
+				c.Add(k, i)
+				m[k] = i
+			}
+			if c.Len() != len(m) || c.Len() > maxItems {
+				t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+			}
+		}
+	}
+}
+
+func TestFlatCacheSecondChance(t *testing.T) {
+	const maxItems = 1000
+	c := NewFlatCache[int, int](maxItems)
+	for i := range maxItems {
+		c.Add(i, i)
+	}
+	// reference the even keys so that only odd keys are evicted
+	for i := 0; i < maxItems; i += 2 {
+		c.Get(i)
+	}
+	for i := maxItems; i < maxItems+maxItems/2; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < maxItems; i += 2 {
+		if _, ok := c.Get(i); !ok {
+			t.Fatalf("referenced key %d was evicted", i)
+		}
+	}
+}
+
+func TestNewFlatCacheOptions(t *testing.T) {
+	for _, opt := range []Option{WithLRU(), WithTableSizeLog2(4)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic")
+				}
+			}()
+			NewFlatCache[int, int](10, opt)
+		}()
+	}
+}
+
+// BenchmarkFlatHit compares the Get hit of a FlatCache and of a Cache
+// holding size items.
+func BenchmarkFlatHit(b *testing.B) {
+	size := cacheSizes[len(cacheSizes)-1]
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	for _, size := range cacheSizes[3:] {
+		us := ss[:size:size]
+		b.Run(fmt.Sprintf("flat/%8d", size), func(b *testing.B) {
+			c := NewFlatCache[string, int](size)
+			for i, s := range us {
+				c.Add(s, i)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				if _, found := c.Get(us[(i*7919)%size]); !found {
+					b.Fatalf("Key %s should be found", us[(i*7919)%size])
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("cache/%8d", size), func(b *testing.B) {
+			c := NewCache[string, int](size)
+			for i, s := range us {
+				c.Add(s, i)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				if _, found := c.Get(us[(i*7919)%size]); !found {
+					b.Fatalf("Key %s should be found", us[(i*7919)%size])
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkFlatEvict compares the Add of new keys in a full FlatCache and in
+// a full Cache with the CLOCK policy holding size items.
+func BenchmarkFlatEvict(b *testing.B) {
+	size := cacheSizes[len(cacheSizes)-2]
+	ss := make([]string, 2*size)
+	for i := range ss {
+		ss[i] = str(i)
+	}
+	for _, size := range cacheSizes[3 : len(cacheSizes)-1] {
+		b.Run(fmt.Sprintf("flat/%8d", size), func(b *testing.B) {
+			c := NewFlatCache[string, int](size)
+			b.ResetTimer()
+			for i := range b.N {
+				c.Add(ss[i%(2*size)], i)
+			}
+		})
+		b.Run(fmt.Sprintf("cache/%8d", size), func(b *testing.B) {
+			c := NewCache[string, int](size, WithCLOCK())
+			b.ResetTimer()
+			for i := range b.N {
+				c.Add(ss[i%(2*size)], i)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package altmap
+
+import "fmt"
+
+// geometry holds the number of groups and the load thresholds of the tables
+// of a cache created with WithTableSizeLog2, WithMaxLoad or WithMaxTombstones.
+// The tables of the default geometry have a nil geometry and use the
+// constants so that their probing loops use constant masks and bounds, and
+// their groups are allocated inline with the table as a fixedTable.
+type geometry struct {
+	sizeLog2      uint // log base 2 of the number of groups in a table
+	maxUsed       int  // number of used slots triggering a split
+	minUsed       int  // number of items triggering a merge after a deletion
+	maxMerged     int  // maximum number of items of a merged table
+	maxTombstones int  // number of tombstones triggering a rehash
+}
+
+// defaultGeometry is the geometry of the tables with a nil geometry.
+var defaultGeometry = geometry{
+	sizeLog2:      tableSizeLog2,
+	maxUsed:       maxUsed,
+	minUsed:       minUsed,
+	maxMerged:     maxMerged,
+	maxTombstones: maxTombstones,
+}
+
+// maxTableSizeLog2 is the maximum log base 2 of the number of groups in a
+// table of a Cache. It bounds the number of items moved by a split.
+const maxTableSizeLog2 = 12
+
+// newGeometry returns the geometry of the tables of a Cache with 1<<sizeLog2
+// groups, a maximum load maxLoad and a maximum proportion of tombstones
+// maxTombstones, or nil if they are the default values.
+func newGeometry(sizeLog2 int, maxLoad, maxTombstones float64) *geometry {
+	if sizeLog2 < 0 || sizeLog2 > maxTableSizeLog2 {
+		panic(fmt.Sprintf("altmap: table size log2 %d out of range [0, %d]", sizeLog2, maxTableSizeLog2))
+	}
+	g := makeGeometry(uint(sizeLog2), maxLoad, maxTombstones)
+	if g.sizeLog2 == defaultGeometry.sizeLog2 && g.maxUsed == maxUsed && g.maxTombstones == defaultGeometry.maxTombstones {
+		return nil
+	}
+	return g
+}
+
+// makeGeometry returns the geometry of the tables with 1<<sizeLog2 groups,
+// a maximum load maxLoad and a maximum proportion of tombstones
+// maxTombstones. The merge thresholds have the same ratio to maxUsed as with
+// the default geometry.
+func makeGeometry(sizeLog2 uint, maxLoad, maxTombstones float64) *geometry {
+	if maxLoad <= 0 || maxLoad >= 1 {
+		panic(fmt.Sprintf("altmap: max load %v out of range ]0, 1[", maxLoad))
+	}
+	if maxTombstones <= 0 || maxTombstones >= 1 {
+		panic(fmt.Sprintf("altmap: max tombstones %v out of range ]0, 1[", maxTombstones))
+	}
+	items := nItems << sizeLog2
+	g := &geometry{
+		sizeLog2: sizeLog2,
+		// a table keeps a free slot to end the probing
+		maxUsed:       min(max(int(float64(items)*maxLoad), 1), items-2),
+		maxTombstones: max(int(float64(items)*maxTombstones), 1),
+	}
+	g.minUsed = g.maxUsed * 5 / 18
+	g.maxMerged = g.maxUsed * 5 / 9
+	return g
+}
+
+// newGeometryTable returns a new table of depth 0 with the geometry g.
+func newGeometryTable[K comparable, V any](g *geometry) *table[K, V] {
+	if g == nil {
+		return newTable[K, V](0)
+	}
+	return &table[K, V]{groups: make([]Group[K, V], 1<<g.sizeLog2), geo: g}
+}
+
+// limits returns the geometry of the table.
+func (t *table[K, V]) limits() *geometry {
+	if t.geo == nil {
+		return &defaultGeometry
+	}
+	return t.geo
+}
+
+// makeIndexGeo returns the index of the first group to probe in a table with a
+// custom geometry. The group index bits of a large table overlap the bits
+// selecting the table in the directory, so that they are mixed with bits
+// unused by the directory.
+func (t *table[K, V]) makeIndexGeo(hash uint) uint {
+	return (H1(hash) ^ hash>>40) & uint(len(t.groups)-1)
+}
+
+// findGeo is find for a table with a custom geometry.
+func (t *table[K, V]) findGeo(key K, hash uint) int {
+	pattern := MakePattern(H2(hash))
+	mask := uint(len(t.groups) - 1)
+	var pos uint
+	idx := t.makeIndexGeo(hash)
+	for {
+		g := &t.groups[idx]
+		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
+			i := set.Pos() & (nItems - 1)
+			if g.item[i].key == key {
+				return int(idx)*nItems + i
+			}
+		}
+		if g.header.HasFreeSlots() {
+			return -1
+		}
+		// the mask avoids a modulo
+		pos++
+		idx = (idx + pos) & mask
+	}
+}
+
+// probeGeo is probe for a table with a custom geometry.
+func (t *table[K, V]) probeGeo(key K, hash uint) (slot int, found bool) {
+	pattern := MakePattern(H2(hash))
+	mask := uint(len(t.groups) - 1)
+	var pos uint
+	idx := t.makeIndexGeo(hash)
+	slot = -1
+	for {
+		g := &t.groups[idx]
+		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
+			i := set.Pos() & (nItems - 1)
+			if g.item[i].key == key {
+				return int(idx)*nItems + i, true
+			}
+		}
+		if slot < 0 {
+			if set := g.header.FindUnused(); !set.Empty() {
+				slot = int(idx)*nItems + set.Pos()&(nItems-1)
+			}
+		}
+		if g.header.HasFreeSlots() {
+			return slot, false
+		}
+		// the mask avoids a modulo
+		pos++
+		idx = (idx + pos) & mask
+	}
+}
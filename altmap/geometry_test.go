@@ -0,0 +1,116 @@
+package altmap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewGeometry(t *testing.T) {
+	if g := newGeometry(tableSizeLog2, 0.9, 0.15); g != nil {
+		t.Fatalf("expect nil default geometry, got %+v", g)
+	}
+	g := newGeometry(0, 0.9, 0.15)
+	if g == nil || g.maxUsed != nItems-2 || g.maxTombstones != 1 {
+		t.Fatalf("expect maxUsed %d and maxTombstones 1, got %+v", nItems-2, g)
+	}
+	for _, args := range []struct {
+		sizeLog2               int
+		maxLoad, maxTombstones float64
+	}{{-1, 0.9, 0.15}, {maxTableSizeLog2 + 1, 0.9, 0.15}, {8, 0, 0.15}, {8, 1, 0.15}, {8, 0.9, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic for %+v", args)
+				}
+			}()
+			newGeometry(args.sizeLog2, args.maxLoad, args.maxTombstones)
+		}()
+	}
+}
+
+// TestCacheGeometryHint checks the size hint of a geometry whose tables
+// hold a single item before a split.
+func TestCacheGeometryHint(t *testing.T) {
+	const n = 10
+	c := NewCache[int, int](n, WithTableSizeLog2(0), WithMaxLoad(0.1))
+	if c.tables[0].geo.maxUsed != 1 || len(c.tables) < n {
+		t.Fatalf("expect maxUsed 1 and %d tables, got %d and %d", n, c.tables[0].geo.maxUsed, len(c.tables))
+	}
+	for i := range n {
+		c.Add(i, i)
+	}
+	for i := range n {
+		if v, ok := c.Get(i); !ok || v != i {
+			t.Fatalf("key %d expect %d true, got %d %v", i, i, v, ok)
+		}
+	}
+}
+
+func TestCacheGeometry(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithTableSizeLog2(0)},
+		{WithTableSizeLog2(3), WithMaxLoad(0.5)},
+		{WithTableSizeLog2(maxTableSizeLog2), WithMaxTombstones(0.05)},
+		{WithMaxLoad(0.97), WithLRU()},
+		{WithTableSizeLog2(5), WithS3FIFO()},
+	} {
+		const n = 50000
+		c := NewCache[int, int](n, opts...)
+		geo := c.tables[0].geo
+		if geo == nil {
+			t.Fatalf("expect a custom geometry")
+		}
+		c.Reset()
+		for i := range n {
+			c.Add(i, i)
+		}
+		for _, tbl := range c.tables {
+			if tbl.geo != geo || len(tbl.groups) != 1<<geo.sizeLog2 {
+				t.Fatalf("expect %d groups, got %d", 1<<geo.sizeLog2, len(tbl.groups))
+			}
+			if int(tbl.nItems) > geo.maxUsed+1 {
+				t.Fatalf("expect at most %d items, got %d", geo.maxUsed+1, tbl.nItems)
+			}
+		}
+		for i := range n {
+			if v, ok := c.Get(i); !ok || v != i {
+				t.Fatalf("key %d expect %d true, got %d %v", i, i, v, ok)
+			}
+		}
+		for i := 0; i < n; i += 2 {
+			c.Del(i)
+		}
+		c.Compact()
+		for i := range n {
+			if v, ok := c.Get(i); ok != (i%2 == 1) || ok && v != i {
+				t.Fatalf("key %d expect %d %v, got %d %v", i, i, i%2 == 1, v, ok)
+			}
+		}
+		if c.Len() != n/2 {
+			t.Fatalf("expect len %d, got %d", n/2, c.Len())
+		}
+	}
+}
+
+func BenchmarkGeometryHit(b *testing.B) {
+	const size = 1000000
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	for _, sizeLog2 := range []int{4, 6, tableSizeLog2, 10, maxTableSizeLog2} {
+		b.Run(fmt.Sprintf("%2d", sizeLog2), func(b *testing.B) {
+			c := NewCache[string, int](0, WithTableSizeLog2(sizeLog2))
+			for i := range size {
+				c.Add(ss[i], i)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				idx := (i * 7919) % size
+				if _, found := c.Get(ss[idx]); !found {
+					b.Fatalf("Key %s should be found", ss[idx])
+				}
+			}
+		})
+	}
+}
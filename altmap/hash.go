@@ -0,0 +1,107 @@
+package altmap
+
+import (
+	"hash/maphash"
+
+	"github.com/zeebo/xxh3"
+)
+
+// Hasher is the interface of key hash functions. Hash must return the same
+// value for the same seed and key.
+type Hasher[K comparable] interface {
+	Hash(seed Seed, key K) uint
+}
+
+// HashFunc is a function returning the hash value of key using the given seed.
+type HashFunc[K comparable] func(seed Seed, key K) uint
+
+// Hash returns f(seed, key).
+func (f HashFunc[K]) Hash(seed Seed, key K) uint {
+	return f(seed, key)
+}
+
+// Integer is the constraint of the integer types.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// HashString returns the xxh3 hash of key using the given seed.
+func HashString(seed Seed, key string) uint {
+	return uint(xxh3.HashStringSeed(key, uint64(seed)))
+}
+
+// StringHasher hashes string keys with xxh3.
+type StringHasher struct{}
+
+// Hash returns the xxh3 hash of key using the given seed.
+func (StringHasher) Hash(seed Seed, key string) uint {
+	return HashString(seed, key)
+}
+
+// IntHasher hashes integer keys with HashUint64.
+type IntHasher[K Integer] struct{}
+
+// Hash returns the HashUint64 hash of key using the given seed.
+func (IntHasher[K]) Hash(seed Seed, key K) uint {
+	return uint(HashUint64(uint64(key), uint64(seed)))
+}
+
+// Int32Hasher hashes integer keys with HashUint32. Only the 32 less
+// significant bits of the key are hashed.
+type Int32Hasher[K Integer] struct{}
+
+// Hash returns the HashUint32 hash of key using the given seed.
+func (Int32Hasher[K]) Hash(seed Seed, key K) uint {
+	return uint(HashUint32(uint32(key), uint64(seed)))
+}
+
+// MapHasher hashes any comparable key with hash/maphash.
+type MapHasher[K comparable] struct {
+	seed maphash.Seed
+}
+
+// NewMapHasher returns a MapHasher with a random maphash seed.
+func NewMapHasher[K comparable]() MapHasher[K] {
+	return MapHasher[K]{seed: maphash.MakeSeed()}
+}
+
+// Hash returns the maphash hash of key combined with the given seed.
+func (h MapHasher[K]) Hash(seed Seed, key K) uint {
+	return uint(maphash.Comparable(h.seed, key) ^ uint64(seed))
+}
+
+// defaultHasher returns the default hasher for keys of type K. String keys
+// are hashed with xxh3, integer keys with HashUint64 and other keys with
+// maphash.
+func defaultHasher[K comparable]() Hasher[K] {
+	var h any
+	switch any(*new(K)).(type) {
+	case string:
+		h = StringHasher{}
+	case int:
+		h = IntHasher[int]{}
+	case int8:
+		h = IntHasher[int8]{}
+	case int16:
+		h = IntHasher[int16]{}
+	case int32:
+		h = IntHasher[int32]{}
+	case int64:
+		h = IntHasher[int64]{}
+	case uint:
+		h = IntHasher[uint]{}
+	case uint8:
+		h = IntHasher[uint8]{}
+	case uint16:
+		h = IntHasher[uint16]{}
+	case uint32:
+		h = IntHasher[uint32]{}
+	case uint64:
+		h = IntHasher[uint64]{}
+	case uintptr:
+		h = IntHasher[uintptr]{}
+	default:
+		return NewMapHasher[K]()
+	}
+	return h.(Hasher[K])
+}
@@ -0,0 +1,94 @@
+package altmap
+
+import "testing"
+
+func TestDefaultHasher(t *testing.T) {
+	seed := MakeSeed()
+	if exp, got := HashString(seed, "abc"), defaultHasher[string]().Hash(seed, "abc"); exp != got {
+		t.Errorf("string: expect %x, got %x", exp, got)
+	}
+	if exp, got := uint(HashUint64(123, uint64(seed))), defaultHasher[int]().Hash(seed, 123); exp != got {
+		t.Errorf("int: expect %x, got %x", exp, got)
+	}
+	if exp, got := uint(HashUint64(123, uint64(seed))), defaultHasher[uint32]().Hash(seed, 123); exp != got {
+		t.Errorf("uint32: expect %x, got %x", exp, got)
+	}
+	h := defaultHasher[float64]()
+	if _, ok := h.(MapHasher[float64]); !ok {
+		t.Errorf("float64: expect MapHasher, got %T", h)
+	}
+	if exp, got := h.Hash(seed, 1.5), h.Hash(seed, 1.5); exp != got {
+		t.Errorf("float64: expect %x, got %x", exp, got)
+	}
+}
+
+func TestCacheHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher Hasher[int]
+	}{
+		{name: "int", hasher: IntHasher[int]{}},
+		{name: "int32", hasher: Int32Hasher[int]{}},
+		{name: "maphash", hasher: NewMapHasher[int]()},
+		{name: "func", hasher: HashFunc[int](func(seed Seed, key int) uint {
+			return uint(HashUint64(uint64(key), uint64(seed)))
+		})},
+	}
+	for _, test := range tests {
+		var c Cache[int, int]
+		c.InitHasher(test.hasher)
+		for i := range 5000 {
+			c.Add(i, i)
+		}
+		for i := range 5000 {
+			if v, ok := c.Get(i); !ok || v != i {
+				t.Fatalf("%s: for key %d expect %d true, got %d %v", test.name, i, i, v, ok)
+			}
+		}
+	}
+}
+
+func BenchmarkHasherString(b *testing.B) {
+	seed := MakeSeed()
+	hashers := []struct {
+		name   string
+		hasher Hasher[string]
+	}{
+		{name: "xxh3", hasher: StringHasher{}},
+		{name: "maphash", hasher: NewMapHasher[string]()},
+	}
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = str(i)
+	}
+	for _, h := range hashers {
+		b.Run(h.name, func(b *testing.B) {
+			var sum uint
+			for i := 0; i < b.N; i++ {
+				sum += h.hasher.Hash(seed, keys[i&(len(keys)-1)])
+			}
+			_ = sum
+		})
+	}
+}
+
+func BenchmarkHasherInt(b *testing.B) {
+	seed := MakeSeed()
+	hashers := []struct {
+		name   string
+		hasher Hasher[int]
+	}{
+		{name: "xxh3-64", hasher: IntHasher[int]{}},
+		{name: "xxh3-32", hasher: Int32Hasher[int]{}},
+		{name: "maphash", hasher: NewMapHasher[int]()},
+	}
+	for _, h := range hashers {
+		b.Run(h.name, func(b *testing.B) {
+			var sum uint
+			for i := 0; i < b.N; i++ {
+				sum += h.hasher.Hash(seed, i)
+			}
+			_ = sum
+		})
+	}
+}
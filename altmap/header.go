@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"math/bits"
 	"unsafe"
-
-	"github.com/zeebo/xxh3"
 )
 
 /*
@@ -44,10 +42,6 @@ func MakeSeed() Seed {
 	return Seed(binary.LittleEndian.Uint64(buf[:]))
 }
 
-func (s Seed) Hash(key string) uint {
-	return uint(xxh3.HashStringSeed(key, uint64(s)))
-}
-
 func H1(hash uint) uint {
 	return hash >> topHashBits
 }
@@ -0,0 +1,181 @@
+package altmap
+
+// splitStep is the number of items of a table being split that are copied
+// to its halves by an insertion with incremental splits.
+const splitStep = 2
+
+// dirStep is the number of directory entries copied to the next directory by
+// an insertion with incremental splits.
+const dirStep = 4
+
+/*
+With incremental splits, a full table is replaced in the directory by two
+empty halves, and a migration record of the old table and its halves is
+shared by the three tables and kept in Cache.splits. The items of the
+old table are copied to the halves splitStep items at a time by the next
+insertions, so that an insertion never moves more than splitStep items. An
+insertion in a half copies the items of its old table, and other insertions
+copy the items of the oldest table being split, so that few splits are in
+progress and few lookups check two tables.
+
+As a half receives at most one new item per splitStep copied items, its
+split ends before it is full, unless most items of the old table go to the
+same half. The remaining items are then copied at once.
+
+The old table is never modified by the copy. An item of the old table is
+live while its slot is not yet copied, and is ignored afterwards as its copy
+is then in a half. Live items are updated and deleted in place in the old
+table, and new keys are always added to the halves.
+
+The directory is doubled by the split of a table having its depth. With
+incremental splits, the insertions copy the directory dirStep entries at a
+time to both halves of the next directory of twice its size, and the
+entries modified after their copy are copied again. The doubling then
+replaces the directory by the next one, copying only the entries left.
+*/
+
+// WithIncrementalSplit selects incremental splits where the items of a full
+// table are moved to its two halves by the following insertions instead of
+// the insertion that filled the table. It bounds the latency of Add at the
+// cost of lookups checking the table being split while it is not fully
+// copied. It is not supported with an eviction policy.
+func WithIncrementalSplit() Option {
+	return func(c *config) {
+		c.incremental = true
+	}
+}
+
+// migration is the state of a table being split incrementally.
+type migration[K comparable, V any] struct {
+	old      *table[K, V]    // table being split
+	halves   [2]*table[K, V] // tables receiving its items, nil when copied
+	migrated int             // number of slots of old copied to the halves
+}
+
+// splitting returns true if t is a table being split, and not a half.
+func (t *table[K, V]) splitting() bool {
+	return t.migration != nil && t.migration.old == t
+}
+
+// lookup returns the table and slot of the item with the given key in t or
+// in the table being split into t where it is live. Returns t and -1 if the
+// key is not found. Requires t is not a table being split.
+func (t *table[K, V]) lookup(key K, hash uint) (*table[K, V], int) {
+	slot := t.find(key, hash)
+	if m := t.migration; slot < 0 && m != nil {
+		if i := m.old.find(key, hash); i >= 0 && i >= m.migrated {
+			return m.old, i
+		}
+	}
+	return t, slot
+}
+
+// splitIncremental replaces the full table t by two empty halves of depth
+// t.depth+1 whose items are copied by migrate.
+func (c *Cache[K, V]) splitIncremental(t *table[K, V]) (t1, t2 *table[K, V]) {
+	t1, t2 = t.derive(t.depth+1), t.derive(t.depth+1)
+	t1.touch()
+	t2.touch()
+	m := &migration[K, V]{old: t, halves: [2]*table[K, V]{t1, t2}}
+	t.migration, t1.migration, t2.migration = m, m, m
+	c.splits = append(c.splits, m)
+	return t1, t2
+}
+
+// touch writes the headers of the empty table t, so that the page faults of
+// its fresh memory happen at once by the split rather than by the insertions
+// copying the items.
+func (t *table[K, V]) touch() {
+	for g := range t.groups {
+		t.groups[g].header = 0
+	}
+}
+
+// migrate copies splitStep items of the table being split into t, or of the
+// oldest table being split if t has no old table, and dirStep entries of the
+// directory to the next directory.
+func (c *Cache[K, V]) migrate(t *table[K, V]) {
+	if c.next == nil || c.copied < len(c.tables) {
+		c.copyDir(dirStep)
+	}
+	if t.migration != nil {
+		c.copyItems(t.migration, splitStep)
+		return
+	}
+	for len(c.splits) > 0 && c.splits[0].halves[0] == nil {
+		c.splits[0] = nil
+		c.splits = c.splits[1:]
+	}
+	if len(c.splits) > 0 {
+		c.copyItems(c.splits[0], splitStep)
+	}
+}
+
+// copyItems copies the next n items of the table being split by m to its
+// halves, and ends the split when all its slots are copied.
+func (c *Cache[K, V]) copyItems(m *migration[K, V], n int) {
+	t := m.old
+	bit := uint(1) << (t.depth + tableHashBits)
+	slot, end := m.migrated, t.cap()
+	for slot < end && n > 0 {
+		g, p := slot/nItems, slot%nItems
+		// the used slots of the group from slot
+		set := t.groups[g].header.FindUsed() &^ Set(uint(1)<<(p*8)-1)
+		if set.Empty() {
+			slot = (g + 1) * nItems
+			continue
+		}
+		i := g*nItems + set.Pos()
+		hash := c.hasher.Hash(c.seed, t.item(i).key)
+		if hash&bit == 0 {
+			m.halves[0].move(t, i, hash)
+		} else {
+			m.halves[1].move(t, i, hash)
+		}
+		slot = i + 1
+		n--
+	}
+	m.migrated = slot
+	if slot == end {
+		t.migration, m.halves[0].migration, m.halves[1].migration = nil, nil, nil
+		m.halves = [2]*table[K, V]{}
+		c.retire(t)
+	}
+}
+
+// copyDir copies the next n entries of the directory to both halves of the
+// next directory, that is allocated first if needed.
+func (c *Cache[K, V]) copyDir(n int) {
+	l := len(c.tables)
+	if c.next == nil {
+		c.next = make([]*table[K, V], 2*l)
+		c.copied = 0
+	}
+	end := min(c.copied+n, l)
+	copy(c.next[c.copied:end], c.tables[c.copied:end])
+	copy(c.next[l+c.copied:l+end], c.tables[c.copied:end])
+	c.copied = end
+}
+
+// mirror copies again the entries first+k*step of the directory already
+// copied to the next directory.
+func (c *Cache[K, V]) mirror(first, step uint) {
+	if c.next == nil {
+		return
+	}
+	l := uint(len(c.tables))
+	for i := first; i < uint(c.copied); i += step {
+		c.next[i], c.next[i+l] = c.tables[i], c.tables[i]
+	}
+}
+
+// finishSplits copies all the items of the tables being split.
+func (c *Cache[K, V]) finishSplits() {
+	for _, m := range c.splits {
+		if m.halves[0] != nil {
+			c.copyItems(m, m.old.cap())
+		}
+	}
+	clear(c.splits)
+	c.splits = c.splits[:0]
+}
@@ -0,0 +1,168 @@
+package altmap
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestIncrementalSplit checks that the cache content matches the added keys
+// minus the deleted keys while tables are being split.
+func TestIncrementalSplit(t *testing.T) {
+	for _, opts := range [][]Option{{WithIncrementalSplit()}, {WithIncrementalSplit(), WithTableSizeLog2(2)}} {
+		rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+		m := map[int]int{}
+		c := NewCache[int, int](0, opts...)
+		var pending int
+		for i := range 200000 {
+			k := rng.IntN(100000)
+			switch rng.IntN(10) {
+			case 0:
+				c.Del(k)
+				delete(m, k)
+			case 1, 2, 3:
+				v, ok := c.Get(k)
+				if exp, found := m[k]; found != ok || exp != v {
+					t.Fatalf("%d get %d expect %d %v, got %d %v", i, k, exp, found, v, ok)
+				}
+			default:
+				c.Add(k, i)
+				m[k] = i
+			}
+			if len(c.splits) > 0 {
+				pending++
+			}
+			if c.Len() != len(m) {
+				t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+			}
+		}
+		if pending == 0 {
+			t.Fatalf("expect tables being split")
+		}
+		var n int
+		for k, v := range c.All() {
+			if exp, ok := m[k]; !ok || exp != v {
+				t.Fatalf("key %d value %d, expect %d %v", k, v, exp, ok)
+			}
+			n++
+		}
+		if n != len(m) || len(c.splits) != 0 {
+			t.Fatalf("expect %d items and no split, got %d and %d", len(m), n, len(c.splits))
+		}
+	}
+}
+
+// TestIncrementalDirectory checks that the copied entries of the next
+// directory match the directory while the cache grows and shrinks.
+func TestIncrementalDirectory(t *testing.T) {
+	c := NewCache[int, int](0, WithIncrementalSplit(), WithTableSizeLog2(2))
+	check := func(i int) {
+		l := len(c.tables)
+		if c.next == nil {
+			return
+		}
+		if len(c.next) != 2*l || c.copied > l {
+			t.Fatalf("%d next directory of %d entries with %d copied for %d entries", i, len(c.next), c.copied, l)
+		}
+		for j, tb := range c.tables[:c.copied] {
+			if c.next[j] != tb || c.next[j+l] != tb {
+				t.Fatalf("%d next directory entry %d doesn't match", i, j)
+			}
+		}
+	}
+	const n = 20000
+	for round := range 2 {
+		var doubled bool
+		for i := range n {
+			depth := c.depth
+			c.Add(i, i)
+			doubled = doubled || c.depth > depth
+			check(i)
+		}
+		// tables being split are not merged
+		c.finishSplits()
+		for i := range n - 100 {
+			c.Del(i)
+			check(i)
+		}
+		if !doubled || c.depth > 4 {
+			t.Fatalf("round %d expect the directory to grow and shrink, got depth %d", round, c.depth)
+		}
+		for i := n - 100; i < n; i++ {
+			if v, ok := c.Get(i); !ok || v != i {
+				t.Fatalf("round %d key %d expect %d, got %d %v", round, i, i, v, ok)
+			}
+		}
+	}
+}
+
+func TestIncrementalSplitTTL(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[int, int](0, WithIncrementalSplit(), WithClock(clk.now))
+	const n = 50000
+	for i := range n {
+		c.AddWithTTL(i, i, time.Duration(i%2+1)*time.Second)
+	}
+	clk.t = clk.t.Add(time.Second)
+	for i := range n {
+		if v, ok := c.Get(i); ok != (i%2 == 1) || ok && v != i {
+			t.Fatalf("key %d expect %d %v, got %d %v", i, i, i%2 == 1, v, ok)
+		}
+	}
+	if c.Len() != n/2 {
+		t.Fatalf("expect len %d, got %d", n/2, c.Len())
+	}
+}
+
+func TestIncrementalSplitPolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expect panic")
+		}
+	}()
+	NewCache[int, int](10, WithLRU(), WithIncrementalSplit())
+}
+
+// BenchmarkAddLatency measures the latency of each Add of size new keys in an
+// empty cache with synchronous and incremental splits, and reports its
+// percentiles. The latency histogram is logged with -v.
+func BenchmarkAddLatency(b *testing.B) {
+	const size = 1000000
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	lat := make([]time.Duration, size)
+	for _, mode := range []struct {
+		name string
+		opts []Option
+	}{{"sync", nil}, {"incremental", []Option{WithIncrementalSplit()}}} {
+		b.Run(mode.name, func(b *testing.B) {
+			var hist [32]int // number of Add by power of two of nanoseconds
+			for range b.N {
+				c := NewCache[string, int](0, mode.opts...)
+				for i, s := range ss {
+					start := time.Now()
+					c.Add(s, i)
+					lat[i] = time.Since(start)
+				}
+				for _, d := range lat {
+					hist[min(bits.Len(uint(d)), len(hist)-1)]++
+				}
+			}
+			slices.Sort(lat)
+			for _, p := range []float64{50, 99, 99.9, 99.99} {
+				b.ReportMetric(float64(lat[int(p*size/100)]), fmt.Sprintf("p%v-ns", p))
+			}
+			b.ReportMetric(float64(lat[size-1]), "max-ns")
+			for i, n := range hist {
+				if n > 0 {
+					b.Logf("< %10d ns: %d", 1<<i, n)
+				}
+			}
+		})
+	}
+}
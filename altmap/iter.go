@@ -0,0 +1,114 @@
+package altmap
+
+import (
+	"iter"
+	"slices"
+)
+
+// All returns an iterator over the keys and values of the cache. The order
+// of the items is unspecified and the iteration doesn't count as an access
+// for the eviction policy. Expired items are skipped.
+//
+// The cache may be modified during the iteration. The iteration visits the
+// tables of the directory when it started, and as items are never moved in
+// a table, an item that is neither added nor deleted during the iteration is
+// yielded exactly once. An added item may or may not be yielded. When a table
+// is split or rehashed after the iteration started, the iteration continues
+// with the replaced table, so that a deleted item may still be yielded and
+// the yielded value of an updated item may be its previous value.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.finishSplits()
+		tables := slices.Clone(c.tables)
+		var now int64
+		for i, t := range tables {
+			if uint(i) >= 1<<t.depth {
+				// table already visited
+				continue
+			}
+			if t.expires != nil && now == 0 {
+				now = c.now().UnixNano()
+			}
+			for g := range t.groups {
+				grp := &t.groups[g]
+				for j := range nItems {
+					// the header is read again as yield may modify the group
+					if byte(grp.header>>(j*8))&0x7F == 0 {
+						continue
+					}
+					if t.expires != nil {
+						if e := t.expires[g*nItems+j]; e != 0 && e <= now {
+							continue
+						}
+					}
+					if !yield(grp.item[j].key, grp.item[j].value) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the cache with the same
+// semantic as All.
+func (c *Cache[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range c.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values of the cache with the same
+// semantic as All.
+func (c *Cache[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range c.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// DeleteFunc deletes the items for which del returns true and returns the
+// number of deleted items. Expired items are skipped. The items are deleted
+// in place while the tables are scanned, and the rehash of the tables with
+// too many tombstones is deferred until the end of the scan. del must not
+// modify the cache.
+func (c *Cache[K, V]) DeleteFunc(del func(key K, value V) bool) int {
+	c.finishSplits()
+	var count int
+	var now int64
+	var rehash []uint // directory index of the tables to rehash
+	for i, t := range c.tables {
+		if uint(i) >= 1<<t.depth {
+			// table already visited
+			continue
+		}
+		if t.expires != nil && now == 0 {
+			now = c.now().UnixNano()
+		}
+		for slot := range t.slots() {
+			if t.expires != nil {
+				if e := t.expires[slot]; e != 0 && e <= now {
+					continue
+				}
+			}
+			if item := t.item(slot); del(item.key, item.value) {
+				c.delSlot(t, slot)
+				count++
+			}
+		}
+		if int(t.nTombstones) > t.limits().maxTombstones {
+			rehash = append(rehash, uint(i))
+		}
+	}
+	for _, i := range rehash {
+		c.rehash(c.tables[i], i)
+	}
+	return count
+}
@@ -0,0 +1,132 @@
+package altmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheAll(t *testing.T) {
+	var c Cache[string, int]
+	c.Init()
+	const n = 20000
+	for i := range n {
+		c.Add(str(i), i)
+	}
+	seen := make(map[string]int, n)
+	for k, v := range c.All() {
+		if str(v) != k {
+			t.Fatalf("key %q got value %d", k, v)
+		}
+		seen[k]++
+	}
+	if len(seen) != n {
+		t.Fatalf("expect %d keys, got %d", n, len(seen))
+	}
+	for k, count := range seen {
+		if count != 1 {
+			t.Fatalf("key %q yielded %d times", k, count)
+		}
+	}
+
+	var nKeys, sum int
+	for range c.Keys() {
+		nKeys++
+	}
+	for v := range c.Values() {
+		sum += v
+	}
+	if nKeys != n || sum != n*(n-1)/2 {
+		t.Fatalf("expect %d keys and sum %d, got %d and %d", n, n*(n-1)/2, nKeys, sum)
+	}
+
+	var count int
+	for range c.All() {
+		if count++; count == 10 {
+			break
+		}
+	}
+	if count != 10 {
+		t.Fatalf("expect iteration to stop at 10, got %d", count)
+	}
+}
+
+// TestCacheAllMutation checks that the items neither added nor deleted during
+// the iteration are yielded once while other items are added, splitting the
+// tables, and deleted.
+func TestCacheAllMutation(t *testing.T) {
+	var c Cache[int, int]
+	c.Init()
+	const n = 10000
+	for i := range n {
+		c.Add(i, i)
+	}
+	seen := make(map[int]int, n)
+	next := n
+	for k := range c.All() {
+		seen[k]++
+		for range 3 {
+			c.Add(next, next)
+			next++
+		}
+		if k%2 == 0 {
+			c.Del(k + 1)
+		}
+	}
+	for i := range n {
+		if seen[i] != 1 && (i%2 == 0 || seen[i] > 1) {
+			t.Fatalf("key %d yielded %d times", i, seen[i])
+		}
+	}
+}
+
+func TestCacheAllExpired(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[int, int](0, WithClock(clk.now))
+	for i := range 100 {
+		c.AddWithTTL(i, i, time.Duration(i%2+1)*time.Second)
+	}
+	clk.t = clk.t.Add(time.Second)
+	for k := range c.Keys() {
+		if k%2 == 0 {
+			t.Fatalf("expired key %d was yielded", k)
+		}
+	}
+}
+
+func TestCacheDeleteFunc(t *testing.T) {
+	for _, opt := range []Option{WithLRU(), WithS3FIFO()} {
+		const n = 20000
+		c := NewCache[int, int](n, opt)
+		for i := range n {
+			c.Add(i, i)
+		}
+		tables := len(c.tables)
+		count := c.DeleteFunc(func(k, v int) bool {
+			return v%3 != 0
+		})
+		if exp := n - (n+2)/3; count != exp || c.Len() != n-exp {
+			t.Fatalf("expect %d deleted and len %d, got %d and %d", exp, n-exp, count, c.Len())
+		}
+		if len(c.tables) != tables {
+			t.Fatalf("expect %d tables, got %d", tables, len(c.tables))
+		}
+		for i := range n {
+			if _, ok := c.Get(i); ok != (i%3 == 0) {
+				t.Fatalf("key %d expect found %v, got %v", i, i%3 == 0, ok)
+			}
+		}
+		// the rehashed tables have no tombstones
+		for _, tbl := range c.tables {
+			if tbl.nTombstones != 0 {
+				t.Fatalf("expect no tombstones, got %d", tbl.nTombstones)
+			}
+		}
+		// the policy state is consistent with the remaining items
+		for i := n; i < 2*n; i++ {
+			c.Add(i, i)
+		}
+		if c.Len() != n {
+			t.Fatalf("expect len %d, got %d", n, c.Len())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package altmap
+
+import "iter"
+
+// KeySet is a set of keys stored in the tables of a Cache without values, so
+// that a slot holds only a key.
+type KeySet[K comparable] struct {
+	c Cache[K, struct{}]
+}
+
+// NewKeySet returns a new set with the directory and tables allocated up
+// front for sizeHint keys. The eviction policy options are not supported.
+func NewKeySet[K comparable](sizeHint int, options ...Option) *KeySet[K] {
+	if newConfig(options).policy != noPolicy {
+		panic("altmap: KeySet doesn't support eviction policies")
+	}
+	return &KeySet[K]{c: *NewCache[K, struct{}](sizeHint, options...)}
+}
+
+// Init initializes an empty set with the default hasher for K.
+func (s *KeySet[K]) Init() {
+	s.c.Init()
+}
+
+// Len returns the number of keys in the set.
+func (s *KeySet[K]) Len() int {
+	return s.c.Len()
+}
+
+// Add adds key to the set and returns true if it was not in the set.
+func (s *KeySet[K]) Add(key K) bool {
+	_, found := s.c.Add(key, struct{}{})
+	return !found
+}
+
+// Has returns true if key is in the set.
+func (s *KeySet[K]) Has(key K) bool {
+	_, ok := s.c.Get(key)
+	return ok
+}
+
+// Remove removes key from the set and returns true if it was in the set.
+func (s *KeySet[K]) Remove(key K) bool {
+	_, ok := s.c.del(key, s.c.hasher.Hash(s.c.seed, key))
+	return ok
+}
+
+// All returns an iterator over the keys of the set with the same semantic as
+// Cache.All.
+func (s *KeySet[K]) All() iter.Seq[K] {
+	return s.c.Keys()
+}
+
+// Clone returns a copy of the set.
+func (s *KeySet[K]) Clone() *KeySet[K] {
+	return &KeySet[K]{c: *s.c.clone()}
+}
+
+// Union returns a new set with the keys in s or o. The keys of the smaller
+// set are added to a copy of the larger set.
+func (s *KeySet[K]) Union(o *KeySet[K]) *KeySet[K] {
+	if s.Len() < o.Len() {
+		s, o = o, s
+	}
+	r := s.Clone()
+	r.UnionWith(o)
+	return r
+}
+
+// UnionWith adds the keys of o to s.
+func (s *KeySet[K]) UnionWith(o *KeySet[K]) {
+	for k := range o.All() {
+		s.Add(k)
+	}
+}
+
+// Intersect returns a new set with the keys in s and o. The keys of the
+// smaller set are looked up in the larger set.
+func (s *KeySet[K]) Intersect(o *KeySet[K]) *KeySet[K] {
+	if s.Len() > o.Len() {
+		s, o = o, s
+	}
+	r := NewKeySet[K](s.Len(), WithHasher(s.c.hasher))
+	for k := range s.All() {
+		if o.Has(k) {
+			r.Add(k)
+		}
+	}
+	return r
+}
+
+// IntersectWith removes from s the keys not in o. When o is smaller than s,
+// the keys of o are looked up in s and s is replaced by the result.
+func (s *KeySet[K]) IntersectWith(o *KeySet[K]) {
+	if s.Len() > o.Len() {
+		*s = *o.Intersect(s)
+		return
+	}
+	s.c.DeleteFunc(func(k K, _ struct{}) bool {
+		return !o.Has(k)
+	})
+}
+
+// Difference returns a new set with the keys in s and not in o. When o is
+// smaller than s, the keys of o are removed from a copy of s.
+func (s *KeySet[K]) Difference(o *KeySet[K]) *KeySet[K] {
+	if s.Len() > o.Len() {
+		r := s.Clone()
+		r.DifferenceWith(o)
+		return r
+	}
+	r := NewKeySet[K](s.Len(), WithHasher(s.c.hasher))
+	for k := range s.All() {
+		if !o.Has(k) {
+			r.Add(k)
+		}
+	}
+	return r
+}
+
+// DifferenceWith removes from s the keys in o. The keys of the smaller set
+// are looked up in the other set.
+func (s *KeySet[K]) DifferenceWith(o *KeySet[K]) {
+	if s.Len() > o.Len() {
+		for k := range o.All() {
+			s.Remove(k)
+		}
+		return
+	}
+	s.c.DeleteFunc(func(k K, _ struct{}) bool {
+		return o.Has(k)
+	})
+}
+
+// IsSubset returns true if all the keys of s are in o.
+func (s *KeySet[K]) IsSubset(o *KeySet[K]) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	for k := range s.All() {
+		if !o.Has(k) {
+			return false
+		}
+	}
+	return true
+}
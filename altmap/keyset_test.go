@@ -0,0 +1,161 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"unsafe"
+)
+
+func TestKeySet(t *testing.T) {
+	if exp, got := unsafe.Sizeof(0), unsafe.Sizeof(Item[int, struct{}]{}); exp != got {
+		t.Fatalf("expect item size %d, got %d", exp, got)
+	}
+	var s KeySet[string]
+	s.Init()
+	const n = 10000
+	for i := range n {
+		if !s.Add(str(i)) {
+			t.Fatalf("key %q expect added", str(i))
+		}
+	}
+	if s.Add(str(0)) {
+		t.Fatalf("key %q expect not added", str(0))
+	}
+	for i := 0; i < n; i += 2 {
+		if !s.Remove(str(i)) {
+			t.Fatalf("key %q expect removed", str(i))
+		}
+	}
+	if s.Remove(str(0)) {
+		t.Fatalf("key %q expect not removed", str(0))
+	}
+	if s.Len() != n/2 {
+		t.Fatalf("expect len %d, got %d", n/2, s.Len())
+	}
+	for i := range n {
+		if s.Has(str(i)) != (i%2 == 1) {
+			t.Fatalf("key %q expect %v", str(i), i%2 == 1)
+		}
+	}
+	var count int
+	for k := range s.All() {
+		if !s.Has(k) {
+			t.Fatalf("key %q yielded but not in the set", k)
+		}
+		count++
+	}
+	if count != n/2 {
+		t.Fatalf("expect %d keys, got %d", n/2, count)
+	}
+}
+
+// keySetOf returns a set and a map with n random keys in [0, max).
+func keySetOf(rng *rand.Rand, n, max int) (*KeySet[int], map[int]bool) {
+	s := NewKeySet[int](0)
+	m := map[int]bool{}
+	for range n {
+		k := rng.IntN(max)
+		s.Add(k)
+		m[k] = true
+	}
+	return s, m
+}
+
+// checkKeySet fails if the keys of s are not the keys of m.
+func checkKeySet(t *testing.T, name string, s *KeySet[int], m map[int]bool) {
+	t.Helper()
+	if s.Len() != len(m) {
+		t.Fatalf("%s: expect len %d, got %d", name, len(m), s.Len())
+	}
+	for k := range s.All() {
+		if !m[k] {
+			t.Fatalf("%s: unexpected key %d", name, k)
+		}
+	}
+}
+
+func TestKeySetAlgebra(t *testing.T) {
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	for _, sizes := range [][2]int{{0, 100}, {100, 5000}, {5000, 100}, {20000, 20000}} {
+		a, ma := keySetOf(rng, sizes[0], 30000)
+		b, mb := keySetOf(rng, sizes[1], 30000)
+		union, inter, diff := map[int]bool{}, map[int]bool{}, map[int]bool{}
+		subset := true
+		for k := range ma {
+			union[k] = true
+			if mb[k] {
+				inter[k] = true
+			} else {
+				diff[k] = true
+				subset = false
+			}
+		}
+		for k := range mb {
+			union[k] = true
+		}
+		checkKeySet(t, "Union", a.Union(b), union)
+		checkKeySet(t, "Intersect", a.Intersect(b), inter)
+		checkKeySet(t, "Difference", a.Difference(b), diff)
+		if a.IsSubset(b) != subset {
+			t.Fatalf("expect IsSubset %v", subset)
+		}
+
+		r := a.Clone()
+		r.UnionWith(b)
+		checkKeySet(t, "UnionWith", r, union)
+		r = a.Clone()
+		r.IntersectWith(b)
+		checkKeySet(t, "IntersectWith", r, inter)
+		r = a.Clone()
+		r.DifferenceWith(b)
+		checkKeySet(t, "DifferenceWith", r, diff)
+		// the operands are not modified
+		checkKeySet(t, "a", a, ma)
+		checkKeySet(t, "b", b, mb)
+		if !a.Intersect(b).IsSubset(a) || !a.IsSubset(a.Union(b)) {
+			t.Fatalf("expect intersection and set to be subsets")
+		}
+	}
+}
+
+func BenchmarkKeySetAdd(b *testing.B) {
+	for _, size := range cacheSizes[3:7] {
+		b.Run(fmt.Sprintf("keyset/%8d", size), func(b *testing.B) {
+			for range b.N {
+				s := NewKeySet[int](size)
+				for i := range size {
+					s.Add(i)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("gomap/%8d", size), func(b *testing.B) {
+			for range b.N {
+				m := make(map[int]struct{}, size)
+				for i := range size {
+					m[i] = struct{}{}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkKeySetIntersect measures the intersection of a set of 1000 keys
+// with sets of increasing size, which iterates the smaller set.
+func BenchmarkKeySetIntersect(b *testing.B) {
+	small := NewKeySet[int](1000)
+	for i := range 1000 {
+		small.Add(i * 7)
+	}
+	for _, size := range cacheSizes[3:7] {
+		large := NewKeySet[int](size)
+		for i := range size {
+			large.Add(i)
+		}
+		b.Run(fmt.Sprintf("%8d", size), func(b *testing.B) {
+			for range b.N {
+				large.Intersect(small)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package altmap
+
+// lruNode is a node of the lru doubly linked list.
+type lruNode[K comparable] struct {
+	key        K
+	prev, next uint32 // node indexes
+}
+
+// lru is the least recently used eviction policy. The slot meta value
+// is the index of the item node in nodes.
+type lru[K comparable, V any] struct {
+	nodes []lruNode[K] // nodes[0] is the list head, next is the most recently used
+	free  uint32       // index of first free node, 0 if none
+}
+
+// newLRU returns an lru policy for maxItems items.
+func newLRU[K comparable, V any](maxItems int) *lru[K, V] {
+	return &lru[K, V]{nodes: make([]lruNode[K], 1, maxItems+1)}
+}
+
+// unlink removes node n from the list.
+func (l *lru[K, V]) unlink(n uint32) {
+	node := &l.nodes[n]
+	l.nodes[node.prev].next = node.next
+	l.nodes[node.next].prev = node.prev
+}
+
+// pushFront inserts node n at the front of the list.
+func (l *lru[K, V]) pushFront(n uint32) {
+	head := &l.nodes[0]
+	node := &l.nodes[n]
+	node.prev, node.next = 0, head.next
+	l.nodes[head.next].prev = n
+	head.next = n
+}
+
+func (l *lru[K, V]) added(t *table[K, V], slot int, key K, hash uint) {
+	n := l.free
+	if n != 0 {
+		l.free = l.nodes[n].next
+		l.nodes[n].key = key
+	} else {
+		n = uint32(len(l.nodes))
+		l.nodes = append(l.nodes, lruNode[K]{key: key})
+	}
+	l.pushFront(n)
+	t.meta[slot] = n
+}
+
+func (l *lru[K, V]) hit(t *table[K, V], slot int) {
+	if n := t.meta[slot]; l.nodes[0].next != n {
+		l.unlink(n)
+		l.pushFront(n)
+	}
+}
+
+func (l *lru[K, V]) removed(t *table[K, V], slot int) {
+	n := t.meta[slot]
+	l.unlink(n)
+	l.nodes[n] = lruNode[K]{next: l.free}
+	l.free = n
+}
+
+func (l *lru[K, V]) victim(c *Cache[K, V]) K {
+	return l.nodes[l.nodes[0].prev].key
+}
+
+func (l *lru[K, V]) clear() {
+	clear(l.nodes)
+	l.nodes = l.nodes[:1]
+	l.free = 0
+}
@@ -0,0 +1,128 @@
+package altmap
+
+import (
+	"container/list"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestLRUEvict(t *testing.T) {
+	var evicted []string
+	c := NewCache[string, int](3, WithLRU(), WithEvictCallback(func(k string, v int) {
+		evicted = append(evicted, k)
+	}))
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+	c.Get("a")     // b is now the least recently used
+	c.Add("c", 30) // c is now the most recently used
+	c.Add("d", 4)  // evicts b
+	c.Add("e", 5)  // evicts a
+	if exp, got := 3, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	if exp, got := "b a", strings.Join(evicted, " "); exp != got {
+		t.Fatalf("expect evicted %q, got %q", exp, got)
+	}
+	for _, key := range []string{"c", "d", "e"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("expect key %q to be found", key)
+		}
+	}
+	if v, _ := c.Get("c"); v != 30 {
+		t.Fatalf("expect 30, got %d", v)
+	}
+}
+
+// TestLRUModel compares the cache with a reference lru implementation
+// on a random workload large enough to split and rehash tables.
+func TestLRUModel(t *testing.T) {
+	const maxItems = 5000
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	c := NewCache[int, int](maxItems, WithLRU())
+
+	order := list.New() // front is the most recently used
+	elems := map[int]*list.Element{}
+	touch := func(k int) {
+		if e, ok := elems[k]; ok {
+			order.MoveToFront(e)
+			return
+		}
+		if order.Len() == maxItems {
+			e := order.Back()
+			delete(elems, e.Value.(int))
+			order.Remove(e)
+		}
+		elems[k] = order.PushFront(k)
+	}
+
+	for i := range 100000 {
+		k := rng.IntN(3 * maxItems)
+		switch rng.IntN(10) {
+		case 0:
+			c.Del(k)
+			if e, ok := elems[k]; ok {
+				delete(elems, k)
+				order.Remove(e)
+			}
+		case 1, 2, 3:
+			_, ok := c.Get(k)
+			if _, exp := elems[k]; exp != ok {
+				t.Fatalf("%d get %d expect %v, got %v", i, k, exp, ok)
+			}
+			if ok {
+				touch(k)
+			}
+		default:
+			c.Add(k, i)
+			touch(k)
+		}
+		if c.Len() != order.Len() {
+			t.Fatalf("%d expect len %d, got %d", i, order.Len(), c.Len())
+		}
+	}
+	for k := range elems {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expect key %d to be found", k)
+		}
+	}
+}
+
+func TestNewCacheMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "hasher", opt: WithHasher[int](IntHasher[int]{})},
+		{name: "callback", opt: WithEvictCallback(func(string, string) {})},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expect panic", test.name)
+				}
+			}()
+			NewCache[string, int](10, WithLRU(), test.opt)
+		}()
+	}
+}
+
+func BenchmarkLRUHit(b *testing.B) {
+	size := cacheSizes[5]
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+	}
+	c := NewCache[string, int](size, WithLRU())
+	for i := range size {
+		c.Add(ss[i], i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, found := c.Get(ss[i%size]); !found {
+			b.Fatalf("Key %s should be found", ss[i%size])
+		}
+	}
+}
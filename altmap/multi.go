@@ -0,0 +1,151 @@
+package altmap
+
+import "iter"
+
+// inlineValues is the number of values of a key stored in the item of a
+// MultiCache before spilling to a slice.
+const inlineValues = 3
+
+// values is the list of values of a key in a MultiCache. The first
+// inlineValues values are stored inline and the next ones in spill.
+type values[V comparable] struct {
+	n      int             // number of values
+	inline [inlineValues]V // first values
+	spill  []V             // values after the inline values, may be nil
+}
+
+// at returns the value at index i.
+func (l *values[V]) at(i int) V {
+	if i < inlineValues {
+		return l.inline[i]
+	}
+	return l.spill[i-inlineValues]
+}
+
+// append adds v at the end of the list.
+func (l *values[V]) append(v V) {
+	if l.n < inlineValues {
+		l.inline[l.n] = v
+	} else {
+		l.spill = append(l.spill, v)
+	}
+	l.n++
+}
+
+// remove deletes the value at index i keeping the order of the values. The
+// spill slice is never modified in place as GetAll iterations may read it,
+// and it is released when it becomes empty.
+func (l *values[V]) remove(i int) {
+	if i < inlineValues {
+		copy(l.inline[i:], l.inline[i+1:])
+		l.inline[inlineValues-1] = *new(V)
+		if len(l.spill) > 0 {
+			l.inline[inlineValues-1] = l.spill[0]
+			i = inlineValues
+		}
+	}
+	if i >= inlineValues {
+		j := i - inlineValues
+		var spill []V
+		if len(l.spill) > 1 {
+			spill = make([]V, 0, len(l.spill)-1)
+			spill = append(append(spill, l.spill[:j]...), l.spill[j+1:]...)
+		}
+		l.spill = spill
+	}
+	l.n--
+}
+
+// MultiCache is a map of keys to lists of values stored in the tables of a
+// Cache. A list of up to inlineValues values is stored in the item of its
+// key, and longer lists spill to a slice.
+type MultiCache[K comparable, V comparable] struct {
+	c Cache[K, values[V]]
+}
+
+// NewMultiCache returns a new multimap with the directory and tables
+// allocated up front for sizeHint keys. The eviction policy options are not
+// supported.
+func NewMultiCache[K comparable, V comparable](sizeHint int, options ...Option) *MultiCache[K, V] {
+	if newConfig(options).policy != noPolicy {
+		panic("altmap: MultiCache doesn't support eviction policies")
+	}
+	return &MultiCache[K, V]{c: *NewCache[K, values[V]](sizeHint, options...)}
+}
+
+// Init initializes an empty multimap with the default hasher for K.
+func (m *MultiCache[K, V]) Init() {
+	m.c.Init()
+}
+
+// Len returns the number of keys in the multimap.
+func (m *MultiCache[K, V]) Len() int {
+	return m.c.Len()
+}
+
+// values returns the list of values of key with the given hash, or nil if
+// the key is not found. The list is valid until the cache is modified.
+func (m *MultiCache[K, V]) values(key K, hash uint) *values[V] {
+	t, slot := m.c.table(hash).lookup(key, hash)
+	if slot < 0 {
+		return nil
+	}
+	return &t.item(slot).value
+}
+
+// Append adds value at the end of the list of values of key.
+func (m *MultiCache[K, V]) Append(key K, value V) {
+	hash := m.c.hasher.Hash(m.c.seed, key)
+	if l := m.values(key, hash); l != nil {
+		l.append(value)
+		return
+	}
+	var l values[V]
+	l.append(value)
+	m.c.AddHashed(key, l, hash)
+}
+
+// GetAll returns an iterator over the values of key in the order they were
+// appended. The iteration yields the values of key when it starts, even if
+// the multimap is modified during the iteration.
+func (m *MultiCache[K, V]) GetAll(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		l := m.values(key, m.c.hasher.Hash(m.c.seed, key))
+		if l == nil {
+			return
+		}
+		l2 := *l
+		for i := range l2.n {
+			if !yield(l2.at(i)) {
+				return
+			}
+		}
+	}
+}
+
+// DelValue deletes the first occurrence of value in the list of values of
+// key and returns true if it was found. The key is deleted with its last
+// value.
+func (m *MultiCache[K, V]) DelValue(key K, value V) bool {
+	hash := m.c.hasher.Hash(m.c.seed, key)
+	l := m.values(key, hash)
+	if l == nil {
+		return false
+	}
+	for i := range l.n {
+		if l.at(i) == value {
+			if l.n == 1 {
+				m.c.del(key, hash)
+			} else {
+				l.remove(i)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Del deletes key and all its values.
+func (m *MultiCache[K, V]) Del(key K) {
+	m.c.Del(key)
+}
@@ -0,0 +1,63 @@
+package altmap
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestMultiCacheRandom checks that the values of the keys match a map of
+// slices on a random workload of appends and deletes.
+func TestMultiCacheRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	m := map[int][]int{}
+	var mc MultiCache[int, int]
+	mc.Init()
+	for i := range 200000 {
+		k := rng.IntN(5000)
+		v := rng.IntN(8)
+		switch rng.IntN(10) {
+		case 0, 1, 2:
+			ok := mc.DelValue(k, v)
+			j := slices.Index(m[k], v)
+			if ok != (j >= 0) {
+				t.Fatalf("%d DelValue(%d, %d) expect %v, got %v", i, k, v, j >= 0, ok)
+			}
+			if j >= 0 {
+				m[k] = slices.Delete(m[k], j, j+1)
+				if len(m[k]) == 0 {
+					delete(m, k)
+				}
+			}
+		case 3:
+			mc.Del(k)
+			delete(m, k)
+		case 4, 5:
+			if got := slices.Collect(mc.GetAll(k)); !slices.Equal(got, m[k]) {
+				t.Fatalf("%d GetAll(%d) expect %v, got %v", i, k, m[k], got)
+			}
+		default:
+			mc.Append(k, v)
+			m[k] = append(m[k], v)
+		}
+		if mc.Len() != len(m) {
+			t.Fatalf("%d expect len %d, got %d", i, len(m), mc.Len())
+		}
+	}
+}
+
+func TestMultiCacheGetAllMutation(t *testing.T) {
+	mc := NewMultiCache[string, int](0)
+	for i := range 10 {
+		mc.Append("k", i)
+	}
+	var got []int
+	for v := range mc.GetAll("k") {
+		got = append(got, v)
+		mc.DelValue("k", v+1)
+		mc.Append("k", 100+v)
+	}
+	if exp := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(got, exp) {
+		t.Fatalf("expect %v, got %v", exp, got)
+	}
+}
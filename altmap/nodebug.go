@@ -0,0 +1,12 @@
+//go:build !altmap_debug
+
+package altmap
+
+// debugState is empty without the altmap_debug build tag.
+type debugState[K comparable, V any] struct{}
+
+// retire is a no-op without the altmap_debug build tag.
+func (c *Cache[K, V]) retire(t *table[K, V]) {}
+
+// checkStale is a no-op without the altmap_debug build tag.
+func (c *Cache[K, V]) checkStale() {}
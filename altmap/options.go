@@ -0,0 +1,216 @@
+package altmap
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option is a Cache configuration option given to NewCache.
+type Option func(*config)
+
+// config is the Cache configuration set by options.
+type config struct {
+	policy  policyKind       // eviction policy
+	hasher  any              // Hasher[K], nil for the default hasher
+	seed    *Seed            // hash seed, nil for a random seed
+	onEvict any              // func(K, V) called on eviction, may be nil
+	now     func() time.Time // clock, nil for time.Now
+
+	sizeLog2      int     // log base 2 of the number of groups in a table
+	maxLoad       float64 // proportion of used slots triggering a split
+	maxTombstones float64 // proportion of tombstones triggering a rehash
+	incremental   bool    // split the tables incrementally
+}
+
+// policyKind identifies an eviction policy.
+type policyKind byte
+
+const (
+	noPolicy policyKind = iota
+	lruPolicy
+	clockPolicy
+	s3fifoPolicy
+)
+
+// WithLRU selects the least recently used eviction policy. When the cache
+// holds its maximum number of items, adding a new key evicts the least
+// recently used item. Get and Add of an existing key mark the item as
+// used.
+func WithLRU() Option {
+	return func(c *config) {
+		c.policy = lruPolicy
+	}
+}
+
+// WithCLOCK selects the CLOCK (second chance) eviction policy, an
+// approximation of the least recently used policy. Get and Add of an
+// existing key set a reference bit of the item. When the cache holds its
+// maximum number of items, a hand sweeps the slots of the tables, clearing
+// the reference bits, and evicts the first item whose bit is clear.
+func WithCLOCK() Option {
+	return func(c *config) {
+		c.policy = clockPolicy
+	}
+}
+
+// WithS3FIFO selects the S3-FIFO eviction policy. New keys enter a small
+// FIFO queue and are promoted to the main FIFO queue only if accessed again
+// before reaching its tail, so that a scan of keys used once doesn't evict
+// the frequently used keys. Evicted keys of the small queue are remembered
+// in a ghost queue so that they go directly in the main queue when added
+// back.
+func WithS3FIFO() Option {
+	return func(c *config) {
+		c.policy = s3fifoPolicy
+	}
+}
+
+// WithHasher sets the hasher of the keys. As an Option doesn't carry the key
+// type, the compiler doesn't check that the key type of the hasher matches
+// the one of the cache: NewCache, NewFlatCache, NewConcurrentCache and the
+// constructors using NewCache panic when it doesn't.
+func WithHasher[K comparable](hasher Hasher[K]) Option {
+	return func(c *config) {
+		c.hasher = hasher
+	}
+}
+
+// WithSeed sets the hash seed of the cache instead of a random seed. Caches
+// with the same seed and hasher compute the same hash for a key, so that the
+// hash returned by Cache.Hash may be reused to look up the key in each of
+// them. The default hasher of keys other than strings and integers has its
+// own random seed, so that these caches must also share a hasher set by
+// WithHasher. A seed known by an attacker exposes the cache to keys chosen
+// to collide.
+func WithSeed(seed Seed) Option {
+	return func(c *config) {
+		c.seed = &seed
+	}
+}
+
+// WithEvictCallback sets the function called with the key and value of each
+// evicted or expired item. It is not called for items removed by Del or
+// replaced by Add. As an Option doesn't carry the key and value types, the
+// compiler doesn't check that the ones of fn match the ones of the cache:
+// NewCache, NewFlatCache and the constructors using NewCache panic when they
+// don't. MultiCache always panics as its values are stored in an internal
+// type.
+func WithEvictCallback[K comparable, V any](fn func(key K, value V)) Option {
+	return func(c *config) {
+		c.onEvict = fn
+	}
+}
+
+// WithClock sets the function returning the current time used to expire the
+// items added with AddWithTTL. The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// WithTableSizeLog2 sets the log base 2 of the number of groups of 8 slots
+// (4 on 32bit cpu) in a table. The default is 8. Smaller tables reduce
+// the memory footprint of small caches, and larger tables reduce the
+// directory size of large caches. It panics if n is not in [0, 12].
+func WithTableSizeLog2(n int) Option {
+	return func(c *config) {
+		c.sizeLog2 = n
+	}
+}
+
+// WithMaxLoad sets the proportion of used slots, items and tombstones,
+// triggering the split of a table. The default is 0.9. It panics if load is
+// not in ]0, 1[.
+func WithMaxLoad(load float64) Option {
+	return func(c *config) {
+		c.maxLoad = load
+	}
+}
+
+// WithMaxTombstones sets the proportion of tombstones triggering the rehash
+// of a table. The default is 0.15. It panics if p is not in ]0, 1[.
+func WithMaxTombstones(p float64) Option {
+	return func(c *config) {
+		c.maxTombstones = p
+	}
+}
+
+// newConfig returns the configuration set by the options.
+func newConfig(options []Option) *config {
+	cfg := &config{sizeLog2: tableSizeLog2, maxLoad: 0.9, maxTombstones: 0.15}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// configHasher returns the hasher of cfg, or the default hasher for K if not set.
+// It panics if the key type of the hasher doesn't match K.
+func configHasher[K comparable](cfg *config) Hasher[K] {
+	if cfg.hasher == nil {
+		return defaultHasher[K]()
+	}
+	h, ok := cfg.hasher.(Hasher[K])
+	if !ok {
+		panic(fmt.Sprintf("altmap: hasher %T doesn't match key type %T", cfg.hasher, *new(K)))
+	}
+	return h
+}
+
+// NewCache returns a new cache holding at most maxItems items when an
+// eviction policy is selected. Without eviction policy the cache is
+// unbounded and maxItems is a size hint. The directory and its tables are
+// allocated up front so that maxItems items are added without splitting
+// a table, and Del doesn't merge them. Compact merges them when the cache
+// holds less items.
+// It panics if maxItems is not positive with an eviction policy, or if
+// the type of a hasher or callback doesn't match K and V.
+func NewCache[K comparable, V any](maxItems int, options ...Option) *Cache[K, V] {
+	cfg := newConfig(options)
+	c := &Cache[K, V]{}
+	c.InitHasher(configHasher[K](cfg))
+	if cfg.seed != nil {
+		c.seed = *cfg.seed
+	}
+	if geo := newGeometry(cfg.sizeLog2, cfg.maxLoad, cfg.maxTombstones); geo != nil {
+		c.tables[0] = newGeometryTable[K, V](geo)
+	}
+	if cfg.now != nil {
+		c.now = cfg.now
+	}
+	if cfg.incremental {
+		if cfg.policy != noPolicy {
+			panic("altmap: incremental splits are not supported with an eviction policy")
+		}
+		c.incremental = true
+	}
+
+	if cfg.onEvict != nil {
+		fn, ok := cfg.onEvict.(func(K, V))
+		if !ok {
+			panic(fmt.Sprintf("altmap: evict callback %T doesn't match func(%T, %T)", cfg.onEvict, *new(K), *new(V)))
+		}
+		c.onEvict = fn
+	}
+
+	if cfg.policy != noPolicy {
+		if maxItems <= 0 {
+			panic("altmap: maxItems must be positive with an eviction policy")
+		}
+		c.maxItems = maxItems
+		switch cfg.policy {
+		case lruPolicy:
+			c.tables[0].meta = make([]uint32, c.tables[0].cap())
+			c.policy = newLRU[K, V](maxItems)
+		case clockPolicy:
+			c.tables[0].refs = make([]byte, len(c.tables[0].groups))
+			c.policy = &clock[K, V]{}
+		case s3fifoPolicy:
+			c.tables[0].meta = make([]uint32, c.tables[0].cap())
+			c.policy = newS3FIFO[K, V](maxItems)
+		}
+	}
+	c.presize(c.depthFor(maxItems))
+	return c
+}
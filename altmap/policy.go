@@ -0,0 +1,23 @@
+package altmap
+
+// policy is an eviction policy of a bounded cache. The policy may keep per
+// slot data in the meta or refs arrays of the tables, which are moved with
+// the items when tables are split or rehashed.
+type policy[K comparable, V any] interface {
+	// added is called when key with the given hash is stored in the given
+	// slot of table t.
+	added(t *table[K, V], slot int, key K, hash uint)
+
+	// hit is called when the item in the given slot of table t is accessed.
+	hit(t *table[K, V], slot int)
+
+	// removed is called when the item in the given slot of table t is deleted.
+	removed(t *table[K, V], slot int)
+
+	// victim returns the key of the next item to evict from c. Requires the
+	// cache is not empty.
+	victim(c *Cache[K, V]) K
+
+	// clear is called when all the items are deleted.
+	clear()
+}
@@ -0,0 +1,43 @@
+package altmap
+
+/*
+Ptr and PtrOrInsert return a pointer to the value in the slot of its item,
+so that a large value may be read and modified in place without copying it.
+As items are moved to new tables when a table is split, merged or rehashed,
+and a deleted slot is reused by the next insertions, the pointer is only
+valid until the next operation that adds or deletes a key. This includes an
+Add evicting an item, a Get deleting an expired item, and Clear, Reset,
+Compact, DeleteFunc and Sweep. A value written through an invalid pointer is
+lost, or overwrites the value of another item.
+
+With the altmap_debug build tag, the cache keeps a copy of the memory of the
+last tables it replaced, and Ptr, PtrOrInsert and the following table
+replacements panic if a value was written to a replaced table.
+*/
+
+// Ptr returns a pointer to the value associated to key, or nil if the key is
+// not found. The pointer is valid until the next addition or deletion of a
+// key in the cache.
+func (c *Cache[K, V]) Ptr(key K) *V {
+	c.checkStale()
+	hash := c.hasher.Hash(c.seed, key)
+	t, slot := c.lookup(c.table(hash), key, hash)
+	if slot < 0 {
+		return nil
+	}
+	if c.policy != nil {
+		c.policy.hit(t, slot)
+	}
+	return &t.item(slot).value
+}
+
+// PtrOrInsert returns a pointer to the value associated to key and true if it
+// is found, otherwise it adds the key with the zero value and returns a
+// pointer to it and false. The key is found or added by a single probe
+// sequence. The pointer is valid until the next addition or deletion of a
+// key in the cache.
+func (c *Cache[K, V]) PtrOrInsert(key K) (*V, bool) {
+	c.checkStale()
+	t, slot, found := c.upsert(key, c.hasher.Hash(c.seed, key))
+	return &t.item(slot).value, found
+}
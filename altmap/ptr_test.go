@@ -0,0 +1,58 @@
+package altmap
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// bigValue is a value large enough to be updated in place.
+type bigValue struct {
+	n    int
+	data [15]int
+}
+
+// TestPtr checks values updated through Ptr and PtrOrInsert against a go map.
+func TestPtr(t *testing.T) {
+	for name, opts := range upsertOptions {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+			c := NewCache[string, bigValue](20000, opts...)
+			m := map[string]int{}
+			for i := range 100000 {
+				k := str(rng.IntN(20000))
+				switch rng.IntN(8) {
+				case 0:
+					c.Del(k)
+					delete(m, k)
+				case 1, 2:
+					p := c.Ptr(k)
+					if _, ok := m[k]; ok != (p != nil) {
+						t.Fatalf("%d Ptr(%q) expect found %v, got %v", i, k, ok, p != nil)
+					}
+					if p != nil {
+						p.n++
+						m[k]++
+					}
+				default:
+					p, found := c.PtrOrInsert(k)
+					if _, ok := m[k]; ok != found {
+						t.Fatalf("%d PtrOrInsert(%q) expect found %v, got %v", i, k, ok, found)
+					}
+					if !found && p.n != 0 {
+						t.Fatalf("%d PtrOrInsert(%q) expect zero value, got %d", i, k, p.n)
+					}
+					p.n += 2
+					m[k] += 2
+				}
+				if c.Len() != len(m) {
+					t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+				}
+			}
+			for k, n := range m {
+				if v, ok := c.Get(k); !ok || v.n != n {
+					t.Fatalf("key %q expect %d, got %d %v", k, n, v.n, ok)
+				}
+			}
+		})
+	}
+}
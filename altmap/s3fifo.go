@@ -0,0 +1,164 @@
+package altmap
+
+// s3Node is a node of the small or main queue of the s3fifo policy.
+type s3Node[K comparable] struct {
+	key        K
+	hash       uint   // hash of key
+	prev, next uint32 // node indexes
+	freq       uint8  // access frequency, saturating at s3MaxFreq
+	main       bool   // true if the node is in the main queue
+}
+
+// s3MaxFreq is the maximum access frequency of an item.
+const s3MaxFreq = 3
+
+// s3Ghost is an entry of the ghost queue.
+type s3Ghost struct {
+	hash uint   // hash of the evicted key
+	seq  uint64 // insertion sequence number
+}
+
+// s3fifo is the S3-FIFO eviction policy. New keys are inserted in a small
+// FIFO queue holding 10% of the items. Keys accessed while in the small
+// queue are moved to the main FIFO queue when they reach its tail, the
+// others are evicted and their hash is recorded in the ghost queue. Keys
+// found in the ghost queue when added go directly in the main queue. Keys
+// reaching the tail of the main queue are reinserted at its head while
+// their access frequency is not zero, and evicted otherwise.
+//
+// A key accessed only once thus never evicts a key of the main queue. The
+// slot meta value is the index of the item node in nodes.
+type s3fifo[K comparable, V any] struct {
+	nodes    []s3Node[K]     // nodes[0] and nodes[1] are the small and main queue heads
+	free     uint32          // index of first free node, 0 if none
+	nSmall   int             // number of nodes in the small queue
+	maxSmall int             // target number of nodes in the small queue
+	ghosts   []s3Ghost       // ring buffer of the ghost queue
+	ghostSeq map[uint]uint64 // sequence number of the hashes in the ghost queue
+	seq      uint64          // number of hashes added to the ghost queue
+}
+
+const (
+	s3Small = 0 // index of the small queue head node
+	s3Main  = 1 // index of the main queue head node
+)
+
+// newS3FIFO returns an s3fifo policy for maxItems items.
+func newS3FIFO[K comparable, V any](maxItems int) *s3fifo[K, V] {
+	p := &s3fifo[K, V]{
+		nodes:    make([]s3Node[K], 2, maxItems+2),
+		maxSmall: max(maxItems/10, 1),
+		ghosts:   make([]s3Ghost, max(maxItems-maxItems/10, 1)),
+		ghostSeq: make(map[uint]uint64),
+	}
+	p.nodes[s3Main] = s3Node[K]{prev: s3Main, next: s3Main, main: true}
+	return p
+}
+
+// unlink removes node n from its queue.
+func (p *s3fifo[K, V]) unlink(n uint32) {
+	node := &p.nodes[n]
+	p.nodes[node.prev].next = node.next
+	p.nodes[node.next].prev = node.prev
+	if !node.main {
+		p.nSmall--
+	}
+}
+
+// push inserts node n at the head of the queue with the given head node.
+func (p *s3fifo[K, V]) push(head, n uint32) {
+	h := &p.nodes[head]
+	node := &p.nodes[n]
+	node.prev, node.next, node.main = head, h.next, h.main
+	p.nodes[h.next].prev = n
+	h.next = n
+	if !node.main {
+		p.nSmall++
+	}
+}
+
+// addGhost records hash in the ghost queue, dropping the oldest entry when full.
+func (p *s3fifo[K, V]) addGhost(hash uint) {
+	g := &p.ghosts[p.seq%uint64(len(p.ghosts))]
+	if p.seq >= uint64(len(p.ghosts)) && p.ghostSeq[g.hash] == g.seq {
+		delete(p.ghostSeq, g.hash)
+	}
+	p.seq++
+	*g = s3Ghost{hash: hash, seq: p.seq}
+	p.ghostSeq[hash] = p.seq
+}
+
+// takeGhost returns true if hash is in the ghost queue and removes it.
+func (p *s3fifo[K, V]) takeGhost(hash uint) bool {
+	if _, ok := p.ghostSeq[hash]; ok {
+		delete(p.ghostSeq, hash)
+		return true
+	}
+	return false
+}
+
+func (p *s3fifo[K, V]) added(t *table[K, V], slot int, key K, hash uint) {
+	n := p.free
+	if n != 0 {
+		p.free = p.nodes[n].next
+	} else {
+		n = uint32(len(p.nodes))
+		p.nodes = append(p.nodes, s3Node[K]{})
+	}
+	p.nodes[n] = s3Node[K]{key: key, hash: hash}
+	if p.takeGhost(hash) {
+		p.push(s3Main, n)
+	} else {
+		p.push(s3Small, n)
+	}
+	t.meta[slot] = n
+}
+
+func (p *s3fifo[K, V]) hit(t *table[K, V], slot int) {
+	if node := &p.nodes[t.meta[slot]]; node.freq < s3MaxFreq {
+		node.freq++
+	}
+}
+
+func (p *s3fifo[K, V]) removed(t *table[K, V], slot int) {
+	n := t.meta[slot]
+	p.unlink(n)
+	p.nodes[n] = s3Node[K]{next: p.free}
+	p.free = n
+}
+
+func (p *s3fifo[K, V]) victim(c *Cache[K, V]) K {
+	for {
+		if p.nSmall >= p.maxSmall || p.nodes[s3Main].prev == s3Main {
+			n := p.nodes[s3Small].prev
+			node := &p.nodes[n]
+			if node.freq == 0 {
+				p.addGhost(node.hash)
+				return node.key
+			}
+			p.unlink(n)
+			node.freq = 0
+			p.push(s3Main, n)
+			continue
+		}
+		n := p.nodes[s3Main].prev
+		node := &p.nodes[n]
+		if node.freq == 0 {
+			return node.key
+		}
+		node.freq--
+		p.unlink(n)
+		p.push(s3Main, n)
+	}
+}
+
+func (p *s3fifo[K, V]) clear() {
+	clear(p.nodes)
+	p.nodes = p.nodes[:2]
+	p.nodes[s3Main] = s3Node[K]{prev: s3Main, next: s3Main, main: true}
+	p.free = 0
+	p.nSmall = 0
+	clear(p.ghosts)
+	clear(p.ghostSeq)
+	p.seq = 0
+}
@@ -0,0 +1,168 @@
+package altmap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"math/rand/v2"
+	"os"
+	"testing"
+)
+
+func TestS3FIFOScanResistance(t *testing.T) {
+	const maxItems = 1000
+	const nHot = maxItems / 2
+	c := NewCache[int, int](maxItems, WithS3FIFO())
+	for range 3 {
+		for i := range nHot {
+			c.Add(i, i)
+			c.Get(i)
+		}
+	}
+	// scan keys used once
+	for i := nHot; i < 20*maxItems; i++ {
+		c.Add(i, i)
+	}
+	for i := range nHot {
+		if _, ok := c.Get(i); !ok {
+			t.Fatalf("hot key %d was evicted by the scan", i)
+		}
+	}
+	if exp, got := maxItems, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+}
+
+func TestS3FIFORandom(t *testing.T) {
+	const maxItems = 5000
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	m := map[int]int{}
+	c := NewCache[int, int](maxItems, WithS3FIFO(), WithEvictCallback(func(k, v int) {
+		if exp, ok := m[k]; !ok || exp != v {
+			t.Fatalf("evicted key %d value %d, expect %d %v", k, v, exp, ok)
+		}
+		delete(m, k)
+	}))
+	for i := range 100000 {
+		k := rng.IntN(3 * maxItems)
+		switch rng.IntN(10) {
+		case 0:
+			c.Del(k)
+			delete(m, k)
+		case 1, 2, 3:
+			v, ok := c.Get(k)
+			if exp, found := m[k]; found != ok || exp != v {
+				t.Fatalf("%d get %d expect %d %v, got %d %v", i, k, exp, found, v, ok)
+			}
+		default:
+			c.Add(k, i)
+			m[k] = i
+		}
+		if c.Len() != len(m) || c.Len() > maxItems {
+			t.Fatalf("%d expect len %d, got %d", i, len(m), c.Len())
+		}
+	}
+}
+
+// zipfTrace returns a trace of n keys following a zipf distribution over
+// nKeys keys. Every scanEvery keys, a scan of scanLen keys used once is
+// inserted in the trace if scanEvery is not zero.
+func zipfTrace(n, nKeys, scanEvery, scanLen int) []uint64 {
+	rng := rand.New(rand.NewPCG(fixedSeed1, fixedSeed2))
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(nKeys-1))
+	trace := make([]uint64, 0, n)
+	scanKey := uint64(nKeys)
+	for len(trace) < n {
+		if scanEvery > 0 && len(trace)%scanEvery == scanEvery-1 {
+			for range scanLen {
+				trace = append(trace, scanKey)
+				scanKey++
+			}
+		}
+		trace = append(trace, zipf.Uint64())
+	}
+	return trace[:n]
+}
+
+// hitRatio returns the hit ratio of a cache with the given policy option on trace.
+// A missed key is added to the cache.
+func hitRatio(trace []uint64, maxItems int, opt Option) float64 {
+	c := NewCache[uint64, struct{}](maxItems, opt)
+	var hits int
+	for _, k := range trace {
+		if _, ok := c.Get(k); ok {
+			hits++
+		} else {
+			c.Add(k, struct{}{})
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func TestHitRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace []uint64
+	}{
+		{name: "zipf", trace: zipfTrace(500000, 100000, 0, 0)},
+		{name: "zipf+scans", trace: zipfTrace(500000, 100000, 5000, 2000)},
+	}
+	const maxItems = 2000
+	for _, test := range tests {
+		lru := hitRatio(test.trace, maxItems, WithLRU())
+		clock := hitRatio(test.trace, maxItems, WithCLOCK())
+		s3fifo := hitRatio(test.trace, maxItems, WithS3FIFO())
+		t.Logf("%-10s lru %.4f clock %.4f s3fifo %.4f", test.name, lru, clock, s3fifo)
+		if s3fifo < lru {
+			t.Errorf("%s: expect s3fifo hit ratio %.4f >= lru hit ratio %.4f", test.name, s3fifo, lru)
+		}
+	}
+}
+
+// identsTrace returns the trace of testdata/nethttp_idents.txt.gz, recorded
+// by scanning the non test files of net/http from go1.27.1 with go/scanner in
+// file name order, one identifier per line. It is the sequence of lookups in
+// the symbol table of a compiler, where the recently used identifiers are
+// used again. The identifiers are numbered by order of first use.
+func identsTrace(t *testing.T) []uint64 {
+	f, err := os.Open("testdata/nethttp_idents.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]uint64{}
+	var trace []uint64
+	for s := bufio.NewScanner(r); s.Scan(); {
+		id, ok := ids[s.Text()]
+		if !ok {
+			id = uint64(len(ids))
+			ids[s.Text()] = id
+		}
+		trace = append(trace, id)
+	}
+	return trace
+}
+
+// TestHitRatioRecorded checks the hit ratios on a recorded trace holding
+// 10% of its keys. The trace favors recency, so that LRU is the best policy
+// and CLOCK and S3-FIFO must stay close to it.
+func TestHitRatioRecorded(t *testing.T) {
+	trace := identsTrace(t)
+	if len(trace) != 26766 {
+		t.Fatalf("expect 26766 identifiers, got %d", len(trace))
+	}
+	const maxItems = 200
+	lru := hitRatio(trace, maxItems, WithLRU())
+	clock := hitRatio(trace, maxItems, WithCLOCK())
+	s3fifo := hitRatio(trace, maxItems, WithS3FIFO())
+	t.Logf("lru %.4f clock %.4f s3fifo %.4f", lru, clock, s3fifo)
+	if lru < 0.83 {
+		t.Errorf("expect lru hit ratio >= 0.83, got %.4f", lru)
+	}
+	if clock < lru-0.01 || s3fifo < lru-0.01 {
+		t.Errorf("expect clock %.4f and s3fifo %.4f hit ratios within 0.01 of lru %.4f", clock, s3fifo, lru)
+	}
+}
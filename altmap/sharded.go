@@ -0,0 +1,114 @@
+package altmap
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// shard is a Cache protected by a lock.
+type shard[K comparable, V any] struct {
+	sync.RWMutex
+	c Cache[K, V]
+	_ [64]byte // avoid false sharing of the locks
+}
+
+// ShardedCache is a Cache safe for concurrent use. The keys are distributed
+// among independently locked shards selected by the top bits of their hash,
+// which are not used by the directory of the shards.
+type ShardedCache[K comparable, V any] struct {
+	shards    []shard[K, V]
+	seed      Seed      // hash seed shared by the shards
+	hasher    Hasher[K] // hasher shared by the shards
+	shift     uint      // hash shift giving the shard index
+	exclusive bool      // true if Get must take the write lock
+}
+
+// NewShardedCache returns a new sharded cache with nShards shards rounded up to
+// a power of two. The options are applied to each shard. With an eviction
+// policy, maxItems is split evenly among the shards so that each shard holds
+// at most maxItems/nShards items rounded up, and a Get takes the write lock as
+// it updates the policy state. The eviction callback is called with the shard
+// lock held.
+func NewShardedCache[K comparable, V any](nShards, maxItems int, options ...Option) *ShardedCache[K, V] {
+	nShards = 1 << bits.Len(uint(max(nShards, 1)-1))
+	sc := &ShardedCache[K, V]{
+		shards: make([]shard[K, V], nShards),
+		shift:  uint(bits.UintSize - bits.TrailingZeros(uint(nShards))),
+	}
+	perShard := (maxItems + nShards - 1) / nShards
+	for i := range sc.shards {
+		c := &sc.shards[i].c
+		*c = *NewCache[K, V](perShard, options...)
+		if i == 0 {
+			sc.seed, sc.hasher = c.seed, c.hasher
+		}
+		c.seed, c.hasher = sc.seed, sc.hasher
+	}
+	sc.exclusive = sc.shards[0].c.policy != nil
+	return sc
+}
+
+// shard returns the shard of the given hash.
+func (sc *ShardedCache[K, V]) shard(hash uint) *shard[K, V] {
+	// the shift is masked to handle the single shard case where it is 64
+	return &sc.shards[(hash>>(sc.shift&(bits.UintSize-1)))&uint(len(sc.shards)-1)]
+}
+
+// Len returns the number of items stored in the cache.
+func (sc *ShardedCache[K, V]) Len() int {
+	var n int
+	for i := range sc.shards {
+		s := &sc.shards[i]
+		s.RLock()
+		n += s.c.Len()
+		s.RUnlock()
+	}
+	return n
+}
+
+// Get returns the value associated to key and true if it is found.
+func (sc *ShardedCache[K, V]) Get(key K) (value V, ok bool) {
+	hash := sc.hasher.Hash(sc.seed, key)
+	s := sc.shard(hash)
+	if sc.exclusive {
+		s.Lock()
+		value, ok = s.c.GetHashed(key, hash)
+		s.Unlock()
+		return
+	}
+	s.RLock()
+	value, ok = s.c.GetHashed(key, hash)
+	s.RUnlock()
+	return
+}
+
+// Add swaps the value and return true if the key is found in the cache,
+// otherwise it adds the key and value and returns false.
+func (sc *ShardedCache[K, V]) Add(key K, value V) (oldValue V, ok bool) {
+	hash := sc.hasher.Hash(sc.seed, key)
+	s := sc.shard(hash)
+	s.Lock()
+	oldValue, ok = s.c.AddHashed(key, value, hash)
+	s.Unlock()
+	return
+}
+
+// Del deletes key from the cache.
+func (sc *ShardedCache[K, V]) Del(key K) {
+	hash := sc.hasher.Hash(sc.seed, key)
+	s := sc.shard(hash)
+	s.Lock()
+	s.c.del(key, hash)
+	s.Unlock()
+}
+
+// Take deletes key from the cache and returns its value and true if it was
+// found.
+func (sc *ShardedCache[K, V]) Take(key K) (value V, ok bool) {
+	hash := sc.hasher.Hash(sc.seed, key)
+	s := sc.shard(hash)
+	s.Lock()
+	value, ok = s.c.del(key, hash)
+	s.Unlock()
+	return
+}
@@ -0,0 +1,144 @@
+package altmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+func TestShardedCache(t *testing.T) {
+	const nGoroutines = 8
+	const nKeys = 10000
+	sc := NewShardedCache[string, int](16, 0)
+	var wg sync.WaitGroup
+	for g := range nGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range nKeys {
+				key := str(g*nKeys + i)
+				sc.Add(key, i)
+				if v, ok := sc.Get(key); !ok || v != i {
+					t.Errorf("key %q expect %d true, got %d %v", key, i, v, ok)
+					return
+				}
+				if i%4 == 0 {
+					sc.Del(key)
+				} else if i%4 == 2 {
+					if v, ok := sc.Take(key); !ok || v != i {
+						t.Errorf("key %q expect taken %d true, got %d %v", key, i, v, ok)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if exp, got := nGoroutines*nKeys/2, sc.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	for g := range nGoroutines {
+		for i := range nKeys {
+			key := str(g*nKeys + i)
+			if _, ok := sc.Get(key); ok != (i%2 == 1) {
+				t.Fatalf("key %q expect found %v, got %v", key, i%2 == 1, ok)
+			}
+		}
+	}
+}
+
+func TestShardedCacheLRU(t *testing.T) {
+	const maxItems = 1000
+	for _, nShards := range []int{1, 3, 8} {
+		sc := NewShardedCache[int, int](nShards, maxItems, WithLRU())
+		var wg sync.WaitGroup
+		for g := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 10 * maxItems {
+					sc.Add(g*10*maxItems+i, i)
+					sc.Get(g*10*maxItems + i/2)
+				}
+			}()
+		}
+		wg.Wait()
+		perShard := (maxItems + len(sc.shards) - 1) / len(sc.shards)
+		if got := sc.Len(); got > perShard*len(sc.shards) {
+			t.Fatalf("%d shards: expect len <= %d, got %d", nShards, perShard*len(sc.shards), got)
+		}
+	}
+}
+
+// parallelSizes are the cache sizes of the parallel benchmarks.
+var parallelSizes = []int{1000, 100000, 1000000}
+
+// benchParallel runs a parallel benchmark with 90% Get and 10% Add on
+// keys of a cache holding size items.
+func benchParallel(b *testing.B, size int, get func(string) bool, add func(string, int)) {
+	ss := make([]string, size)
+	for i := range size {
+		ss[i] = str(i)
+		add(ss[i], i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+		for pb.Next() {
+			i := rng.IntN(size)
+			if i%10 == 0 {
+				add(ss[i], i)
+			} else if !get(ss[i]) {
+				b.Fatalf("Key %s should be found", ss[i])
+			}
+		}
+	})
+}
+
+func BenchmarkParallel(b *testing.B) {
+	for _, size := range parallelSizes {
+		b.Run(fmt.Sprintf("sharded/%8d", size), func(b *testing.B) {
+			sc := NewShardedCache[string, int](64, 0)
+			benchParallel(b, size, func(k string) bool {
+				_, ok := sc.Get(k)
+				return ok
+			}, func(k string, v int) {
+				sc.Add(k, v)
+			})
+		})
+		b.Run(fmt.Sprintf("seqlock/%8d", size), func(b *testing.B) {
+			c := NewConcurrentCache[string, int]()
+			benchParallel(b, size, func(k string) bool {
+				_, ok := c.Get(k)
+				return ok
+			}, func(k string, v int) {
+				c.Add(k, v)
+			})
+		})
+		b.Run(fmt.Sprintf("rwmutex/%8d", size), func(b *testing.B) {
+			var mu sync.RWMutex
+			var c Cache[string, int]
+			c.Init()
+			benchParallel(b, size, func(k string) bool {
+				mu.RLock()
+				_, ok := c.Get(k)
+				mu.RUnlock()
+				return ok
+			}, func(k string, v int) {
+				mu.Lock()
+				c.Add(k, v)
+				mu.Unlock()
+			})
+		})
+		b.Run(fmt.Sprintf("syncmap/%8d", size), func(b *testing.B) {
+			var m sync.Map
+			benchParallel(b, size, func(k string) bool {
+				_, ok := m.Load(k)
+				return ok
+			}, func(k string, v int) {
+				m.Store(k, v)
+			})
+		})
+	}
+}
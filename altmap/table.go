@@ -2,6 +2,7 @@ package altmap
 
 import (
 	"iter"
+	"slices"
 	"unsafe"
 )
 
@@ -12,12 +13,6 @@ const nItems = uintByteLength
 const tableSizeLog2 = 8
 const tableSize = 1 << tableSizeLog2
 
-// sizeGroup is the byte size of a group.
-const sizeGroup = uint32(unsafe.Sizeof(Group{}))
-
-// sizeGroups is the byte size of all groups.
-const sizeGroups = sizeGroup * tableSize
-
 const tableItems = tableSize * nItems
 
 // number of hash bits used by a table
@@ -26,87 +21,218 @@ const tableHashBits = topHashBits + tableSizeLog2
 // maxUsed is the minimum number of free slots triggering a table split.
 const maxUsed = (tableItems * 90) / 100
 
+// minUsed is the number of items below which a table is merged with its
+// buddy table after a deletion.
+const minUsed = (tableItems * 25) / 100
+
+// maxMerged is the maximum number of items of a table resulting of a merge.
+const maxMerged = (tableItems * 50) / 100
+
 // maxTombstones is the maximum number of tombstones a table should contain.
 const maxTombstones = (tableItems * 15) / 100
 
-type Item struct {
-	key   string
-	value int
+// Item is a key and value stored in a slot. The value is first so that a
+// zero size value, as in KeySet, doesn't add padding after the key.
+type Item[K comparable, V any] struct {
+	value V
+	key   K
 }
 
-type Group struct {
+type Group[K comparable, V any] struct {
 	header Hdr
-	item   [nItems]Item
+	item   [nItems]Item[K, V]
 }
 
-type table struct {
-	groups      [tableSize]Group // array of groups
-	nItems      uint16           // number of items (used only to measure table occupancy)
-	nTombstones uint16           // number of tombstones
+type table[K comparable, V any] struct {
+	groups      []Group[K, V]    // groups, in the array of its fixedTable if geo is nil
+	geo         *geometry        // custom geometry, nil for the default constants
+	meta        []uint32         // per slot eviction policy data, may be nil
+	refs        []byte           // per group slot reference bits, may be nil
+	expires     []int64          // per slot expiration time, may be nil
+	migration   *migration[K, V] // incremental split of or into the table, nil if none
+	nItems      uint32           // number of items (used only to measure table occupancy)
+	nTombstones uint32           // number of tombstones
 	depth       byte             // depth of table in the directory
 }
 
-// newTable returns a new table of the given depth.
-func newTable(depth byte) *table {
-	return &table{depth: depth}
+// fixedTable is the memory of a table with the default geometry. Its groups
+// follow the table fields, so that the probing loops address them at a
+// constant offset of the table pointer without loading the groups slice.
+type fixedTable[K comparable, V any] struct {
+	table[K, V]
+	array [tableSize]Group[K, V]
+}
+
+// newTable returns a new table of the given depth with the default geometry.
+func newTable[K comparable, V any](depth byte) *table[K, V] {
+	ft := &fixedTable[K, V]{}
+	ft.groups = ft.array[:]
+	ft.depth = depth
+	return &ft.table
+}
+
+// array returns the groups of a table with the default geometry. Requires
+// t.geo is nil.
+func (t *table[K, V]) array() *[tableSize]Group[K, V] {
+	return &(*fixedTable[K, V])(unsafe.Pointer(t)).array
+}
+
+// derive returns a new empty table of the given depth with the same
+// per slot arrays as t.
+func (t *table[K, V]) derive(depth byte) *table[K, V] {
+	var t2 *table[K, V]
+	if t.geo == nil {
+		t2 = newTable[K, V](depth)
+	} else {
+		t2 = &table[K, V]{groups: make([]Group[K, V], len(t.groups)), geo: t.geo, depth: depth}
+	}
+	if t.meta != nil {
+		t2.meta = make([]uint32, t.cap())
+	}
+	if t.refs != nil {
+		t2.refs = make([]byte, len(t.groups))
+	}
+	if t.expires != nil {
+		t2.expires = make([]int64, t.cap())
+	}
+	return t2
+}
+
+// clone returns a copy of the table that is not being split.
+func (t *table[K, V]) clone() *table[K, V] {
+	var t2 *table[K, V]
+	if t.geo == nil {
+		t2 = newTable[K, V](t.depth)
+		copy(t2.groups, t.groups)
+	} else {
+		t2 = &table[K, V]{groups: slices.Clone(t.groups), geo: t.geo, depth: t.depth}
+	}
+	t2.meta = slices.Clone(t.meta)
+	t2.refs = slices.Clone(t.refs)
+	t2.expires = slices.Clone(t.expires)
+	t2.nItems = t.nItems
+	t2.nTombstones = t.nTombstones
+	return t2
+}
+
+// clear deletes all the items of the table and its tombstones.
+func (t *table[K, V]) clear() {
+	clear(t.groups)
+	clear(t.meta)
+	clear(t.refs)
+	clear(t.expires)
+	t.nItems = 0
+	t.nTombstones = 0
 }
 
 // len returns the number of items stored in the table.
-func (t *table) len() int {
+func (t *table[K, V]) len() int {
 	return int(t.nItems)
 }
 
 // cap returns the maximum capacity in items of the table.
-func (t *table) cap() int {
-	return tableItems
+func (t *table[K, V]) cap() int {
+	return len(t.groups) * nItems
 }
 
 // occupancy returns the occupancy of the table.
-func (t *table) occupancy() int {
+func (t *table[K, V]) occupancy() int {
 	return (t.len() * 100) / t.cap()
 }
 
-func makeOffset(h1 uint) uint32 {
-	return (uint32(h1) & (tableSize - 1)) * sizeGroup
+// makeIndex returns the index of the first group to probe.
+func makeIndex(h1 uint) uint {
+	return h1 & (tableSize - 1)
+}
+
+// firstGroup returns the first group to probe for hash.
+func (t *table[K, V]) firstGroup(hash uint) *Group[K, V] {
+	if t.geo != nil {
+		return &t.groups[t.makeIndexGeo(hash)]
+	}
+	return &t.groups[makeIndex(H1(hash))]
 }
 
 // get returns the value associated to key if found in the table. hash is the hash value of key.
 // Returns false and the default value if not found.
-func (t *table) get(key *string, hash uint) (value int, ok bool) {
+func (t *table[K, V]) get(key K, hash uint) (value V, ok bool) {
+	if t.geo != nil {
+		if slot := t.findGeo(key, hash); slot >= 0 {
+			return t.item(slot).value, true
+		}
+		return
+	}
+	groups := t.array()
 	pattern := MakePattern(H2(hash))
-	var pos uint32
-	offset := makeOffset(H1(hash))
-	basePtr := unsafe.Pointer(unsafe.SliceData(t.groups[:]))
+	var pos uint
+	idx := makeIndex(H1(hash))
 	for {
-		g := (*Group)(unsafe.Add(basePtr, offset))
+		g := &groups[idx]
 		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
-			if item := &g.item[set.Pos()&(nItems-1)]; item.key == *key {
+			if item := &g.item[set.Pos()&(nItems-1)]; item.key == key {
 				return item.value, true
 			}
 		}
 		if g.header.HasFreeSlots() {
 			return
 		}
-		// to avoid a product by groupSize or a modulo
-		// pos never reach sizeGroups
-		pos += sizeGroup
-		if offset += pos; offset >= sizeGroups {
-			offset -= sizeGroups
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
+	}
+}
+
+// find returns the slot index of the item with the given key, or -1 if not found.
+// hash is the hash value of key.
+func (t *table[K, V]) find(key K, hash uint) int {
+	if t.geo != nil {
+		return t.findGeo(key, hash)
+	}
+	groups := t.array()
+	pattern := MakePattern(H2(hash))
+	var pos uint
+	idx := makeIndex(H1(hash))
+	for {
+		g := &groups[idx]
+		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
+			i := set.Pos() & (nItems - 1)
+			if g.item[i].key == key {
+				return int(idx)*nItems + i
+			}
+		}
+		if g.header.HasFreeSlots() {
+			return -1
 		}
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
 	}
 }
 
+// item returns a pointer on the item in the given slot.
+func (t *table[K, V]) item(slot int) *Item[K, V] {
+	return &t.groups[slot/nItems].item[slot&(nItems-1)]
+}
+
 // swap swaps the value associated with the key if found in the table. hash is the hash of key.
 // Returns the default value and false of the key is not found in the table.
-func (t *table) swap(key *string, value int, hash uint) (oldValue int, ok bool) {
+func (t *table[K, V]) swap(key K, value V, hash uint) (oldValue V, ok bool) {
+	if t.geo != nil {
+		if slot := t.findGeo(key, hash); slot >= 0 {
+			item := t.item(slot)
+			oldValue, item.value = item.value, value
+			return oldValue, true
+		}
+		return
+	}
+	groups := t.array()
 	pattern := MakePattern(H2(hash))
-	var pos uint32
-	offset := makeOffset(H1(hash))
-	basePtr := unsafe.Pointer(unsafe.SliceData(t.groups[:]))
+	var pos uint
+	idx := makeIndex(H1(hash))
 	for {
-		g := (*Group)(unsafe.Add(basePtr, offset))
+		g := &groups[idx]
 		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
-			if item := &g.item[set.Pos()]; item.key == *key {
+			if item := &g.item[set.Pos()&(nItems-1)]; item.key == key {
 				oldValue, item.value = item.value, value
 				return oldValue, true
 			}
@@ -114,46 +240,127 @@ func (t *table) swap(key *string, value int, hash uint) (oldValue int, ok bool)
 		if g.header.HasFreeSlots() {
 			return
 		}
-		// to avoid a product by groupSize or a modulo
-		// pos never reach sizeGroups
-		pos += sizeGroup
-		if offset += pos; offset >= sizeGroups {
-			offset -= sizeGroups
-		}
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
 	}
 }
 
 // add adds the key and value to the table. Requires that the key is not in the
 // table. Returns true if succeeded, and false if the table is full.
-func (t *table) add(key *string, value int, hash uint) bool {
+func (t *table[K, V]) add(key K, value V, hash uint) bool {
+	return t.insert(key, value, hash) >= 0
+}
+
+// insert adds the key and value to the table. Requires that the key is not in the
+// table. Returns the slot index of the item, or -1 if the table is full.
+func (t *table[K, V]) insert(key K, value V, hash uint) int {
+	if t.geo != nil {
+		if int(t.nItems)+int(t.nTombstones) > t.geo.maxUsed {
+			return -1
+		}
+		return t.place(key, value, hash)
+	}
 	if int(t.nItems)+int(t.nTombstones) > maxUsed {
-		return false
+		return -1
 	}
-	var pos uint32
-	offset := makeOffset(H1(hash))
-	basePtr := unsafe.Pointer(unsafe.SliceData(t.groups[:]))
+	groups := t.array()
+	var pos uint
+	idx := makeIndex(H1(hash))
 	for {
-		g := (*Group)(unsafe.Add(basePtr, offset))
+		g := &groups[idx]
 		if set := g.header.FindUnused(); !set.Empty() {
 			// pick first unused slot in header
-			i := set.Pos()
+			i := set.Pos() & (nItems - 1)
 			g.header = g.header.Set(i, H2(hash))
-			g.item[i] = Item{key: *key, value: value}
+			g.item[i] = Item[K, V]{key: key, value: value}
 			t.nItems++
-			return true
+			return int(idx)*nItems + i
 		}
-		// to avoid a product by groupSize or a modulo
-		// pos never reach sizeGroups
-		pos += sizeGroup
-		if offset += pos; offset >= sizeGroups {
-			offset -= sizeGroups
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
+	}
+}
+
+// probe returns the slot index of the item with the given key and true if
+// found, otherwise it returns the first unused slot met by the probe
+// sequence of the key and false. hash is the hash value of key.
+func (t *table[K, V]) probe(key K, hash uint) (slot int, found bool) {
+	if t.geo != nil {
+		return t.probeGeo(key, hash)
+	}
+	groups := t.array()
+	pattern := MakePattern(H2(hash))
+	var pos uint
+	idx := makeIndex(H1(hash))
+	slot = -1
+	for {
+		g := &groups[idx]
+		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
+			i := set.Pos() & (nItems - 1)
+			if g.item[i].key == key {
+				return int(idx)*nItems + i, true
+			}
+		}
+		if slot < 0 {
+			if set := g.header.FindUnused(); !set.Empty() {
+				slot = int(idx)*nItems + set.Pos()&(nItems-1)
+			}
+		}
+		if g.header.HasFreeSlots() {
+			return slot, false
+		}
+		// the mask avoids a modulo and a bound check
+		pos++
+		idx = (idx + pos) & (tableSize - 1)
+	}
+}
+
+// claim adds the key and value in the unused slot returned by probe for the
+// key. Returns false if the table is full.
+func (t *table[K, V]) claim(slot int, key K, value V, hash uint) bool {
+	if int(t.nItems)+int(t.nTombstones) > t.limits().maxUsed {
+		return false
+	}
+	g := &t.groups[slot/nItems]
+	i := slot & (nItems - 1)
+	g.header = g.header.Set(i, H2(hash))
+	g.item[i] = Item[K, V]{key: key, value: value}
+	t.nItems++
+	return true
+}
+
+// place adds the key and value in the first unused slot of the table and
+// returns its slot index. Requires that the key is not in the table and
+// that the table has an unused slot.
+func (t *table[K, V]) place(key K, value V, hash uint) int {
+	var idx uint
+	if t.geo == nil {
+		idx = makeIndex(H1(hash))
+	} else {
+		idx = t.makeIndexGeo(hash)
+	}
+	mask := uint(len(t.groups) - 1)
+	var pos uint
+	for {
+		g := &t.groups[idx]
+		if set := g.header.FindUnused(); !set.Empty() {
+			i := set.Pos() & (nItems - 1)
+			g.header = g.header.Set(i, H2(hash))
+			g.item[i] = Item[K, V]{key: key, value: value}
+			t.nItems++
+			return int(idx)*nItems + i
 		}
+		// the mask avoids a modulo
+		pos++
+		idx = (idx + pos) & mask
 	}
 }
 
-func (t *table) items() iter.Seq2[string, int] {
-	return func(yield func(string, int) bool) {
-		for i := range tableSize {
+func (t *table[K, V]) items() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range t.groups {
 			g := &t.groups[i]
 			h := g.header
 			for j := range nItems {
@@ -168,62 +375,100 @@ func (t *table) items() iter.Seq2[string, int] {
 	}
 }
 
-func (t *table) split(bit uint, seed Seed) (t1, t2 *table) {
+// move adds the item in slot i of table src to t with its per slot data.
+// Requires t has a free slot. The table may exceed its maximum load when
+// a split moves all the items in one table.
+func (t *table[K, V]) move(src *table[K, V], i int, hash uint) {
+	item := src.item(i)
+	slot := t.place(item.key, item.value, hash)
+	if t.meta != nil {
+		t.meta[slot] = src.meta[i]
+	}
+	if t.refs != nil && src.refs[i/nItems]&(1<<(i%nItems)) != 0 {
+		t.refs[slot/nItems] |= 1 << (slot % nItems)
+	}
+	if src.expires != nil {
+		t.setExpires(slot, src.expires[i])
+	}
+}
+
+// slots returns an iterator over the slot indexes of the used slots.
+func (t *table[K, V]) slots() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range t.groups {
+			for set := t.groups[i].header.FindUsed(); !set.Empty(); set = set.Next() {
+				if !yield(i*nItems + set.Pos()) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *table[K, V]) split(bit uint, seed Seed, hasher Hasher[K]) (t1, t2 *table[K, V]) {
 	bit <<= tableHashBits
-	t1, t2 = newTable(t.depth+1), newTable(t.depth+1)
-	for k, v := range t.items() {
-		hash := seed.Hash(k)
+	t1, t2 = t.derive(t.depth+1), t.derive(t.depth+1)
+	for i := range t.slots() {
+		hash := hasher.Hash(seed, t.item(i).key)
 		if hash&bit == 0 {
-			if !t1.add(&k, v, hash) {
-				panic("failed to split")
-			}
+			t1.move(t, i, hash)
 		} else {
-			if !t2.add(&k, v, hash) {
-				panic("failed to split")
-			}
+			t2.move(t, i, hash)
 		}
 	}
 	return t1, t2
 }
 
-// rehash rehashes table to remove all tombstones.
-func (t *table) rehash(seed Seed) *table {
-	t2 := newTable(t.depth)
-	for k, v := range t.items() {
-		if !t2.add(&k, v, seed.Hash(k)) {
-			panic("failed rehashing")
+// merge returns a table of depth t.depth-1 holding the items of t and of
+// its buddy table of the same depth.
+func (t *table[K, V]) merge(buddy *table[K, V], seed Seed, hasher Hasher[K]) *table[K, V] {
+	t2 := t.derive(t.depth - 1)
+	if t2.expires == nil && buddy.expires != nil {
+		t2.expires = make([]int64, t2.cap())
+	}
+	for _, src := range [...]*table[K, V]{t, buddy} {
+		for i := range src.slots() {
+			t2.move(src, i, hasher.Hash(seed, src.item(i).key))
 		}
 	}
 	return t2
 }
 
+// rehash rehashes table to remove all tombstones.
+func (t *table[K, V]) rehash(seed Seed, hasher Hasher[K]) *table[K, V] {
+	t2 := t.derive(t.depth)
+	for i := range t.slots() {
+		t2.move(t, i, hasher.Hash(seed, t.item(i).key))
+	}
+	return t2
+}
+
 // del deletes the item with the given key. Returns true if the number of tombstones
 // exceeds a threshold.
-func (t *table) del(key *string, hash uint) (rehash bool, ok bool) {
-	pattern := MakePattern(H2(hash))
-	var pos uint32
-	offset := makeOffset(H1(hash))
-	basePtr := unsafe.Pointer(unsafe.SliceData(t.groups[:]))
-	for {
-		g := (*Group)(unsafe.Add(basePtr, offset))
-		for set := g.header.Find(pattern); !set.Empty(); set = set.Next() {
-			i := set.Pos()
-			if item := &g.item[i]; item.key == *key {
-				*item = Item{}
-				g.header = g.header.Set(i, tombstone)
-				t.nTombstones++
-				t.nItems--
-				return int(t.nTombstones) > maxTombstones, true
-			}
-		}
-		if g.header.HasFreeSlots() {
-			return false, false
-		}
-		// to avoid a product by groupSize or a modulo
-		// pos never reach sizeGroups
-		pos += sizeGroup
-		if offset += pos; offset >= sizeGroups {
-			offset -= sizeGroups
-		}
+func (t *table[K, V]) del(key K, hash uint) (rehash bool, ok bool) {
+	slot := t.find(key, hash)
+	if slot < 0 {
+		return false, false
+	}
+	_, rehash = t.delSlot(slot)
+	return rehash, true
+}
+
+// delSlot deletes the item in the given used slot and returns its value. Returns
+// true if the number of tombstones exceeds a threshold.
+func (t *table[K, V]) delSlot(slot int) (value V, rehash bool) {
+	g := &t.groups[slot/nItems]
+	i := slot & (nItems - 1)
+	value = g.item[i].value
+	g.item[i] = Item[K, V]{}
+	if t.expires != nil {
+		t.expires[slot] = 0
+	}
+	g.header = g.header.Set(i, tombstone)
+	t.nTombstones++
+	t.nItems--
+	if t.geo != nil {
+		return value, int(t.nTombstones) > t.geo.maxTombstones
 	}
+	return value, int(t.nTombstones) > maxTombstones
 }
@@ -5,13 +5,14 @@ import (
 	"math/rand/v2"
 	"strings"
 	"testing"
+	"unsafe"
 )
 
 func TestTableAddGet(t *testing.T) {
 	seed := MakeSeed()
 	//seed = 0 // for debugging
 
-	c := newTable(0)
+	c := newTable[string, int](0)
 	var i int
 	for i = range 8192 {
 		// t.Log(i)
@@ -19,17 +20,17 @@ func TestTableAddGet(t *testing.T) {
 		// 	print()
 		// }
 		key := str(i)
-		hash := seed.Hash(key)
-		if !c.add(&key, i, hash) {
+		hash := HashString(seed, key)
+		if !c.add(key, i, hash) {
 			break
 		}
-		if _, ok := c.get(&key, hash); !ok {
+		if _, ok := c.get(key, hash); !ok {
 			t.Fatalf("%3d failed to find key %v", i, str(i))
 		}
 
 		for j := range c.nItems {
 			key := str(i)
-			_, ok := c.get(&key, seed.Hash(key))
+			_, ok := c.get(key, HashString(seed, key))
 			if !ok {
 				t.Fatalf("%3d.%d could not find key %q", i, j, key)
 			}
@@ -57,15 +58,24 @@ func TestTableAddGet(t *testing.T) {
 	}
 }
 
+// TestTableHeaderSize checks that the fields of a table stay smaller than the
+// groups of the smallest tables, the state of ConcurrentCache and of the
+// incremental splits being kept outside the table.
+func TestTableHeaderSize(t *testing.T) {
+	if size := unsafe.Sizeof(table[int, int]{}); size > 128 {
+		t.Fatalf("expect a table header of at most 128 bytes, got %d", size)
+	}
+}
+
 func TestTableAddDel(t *testing.T) {
 	seed := MakeSeed()
 	//seed = 0 // for debugging
 
-	c := newTable(0)
+	c := newTable[string, int](0)
 	var keys []string
 	for i := range tableItems {
 		key := str(i)
-		if !c.add(&key, i, seed.Hash(key)) {
+		if !c.add(key, i, HashString(seed, key)) {
 			break
 		}
 		keys = append(keys, key)
@@ -76,12 +86,12 @@ func TestTableAddDel(t *testing.T) {
 	})
 
 	for _, key := range keys {
-		rehash, ok := c.del(&key, seed.Hash(key))
+		rehash, ok := c.del(key, HashString(seed, key))
 		if !ok {
 			t.Fatalf("failed to delete key %q", key)
 		}
 		if rehash {
-			c = c.rehash(seed)
+			c = c.rehash(seed, StringHasher{})
 		}
 	}
 	if c.len() != 0 {
@@ -113,16 +123,16 @@ func BenchmarkTable2Hit(b *testing.B) {
 		})
 
 		b.Run(fmt.Sprintf("tbl2 %3d", size), func(b *testing.B) {
-			c := newTable(0)
+			c := newTable[string, int](0)
 			for i := range size {
 				key := ss[i]
-				c.add(&key, i, seed.Hash(key))
+				c.add(key, i, HashString(seed, key))
 			}
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
 				key := us[i%size]
-				hash := seed.Hash(key)
-				_, found := c.get(&key, hash)
+				hash := HashString(seed, key)
+				_, found := c.get(key, hash)
 				if !found {
 					b.Fatalf("Key %q should be found", key)
 				}
@@ -149,11 +159,11 @@ func BenchmarkTable2Hit(b *testing.B) {
 func BenchmarkGet(b *testing.B) {
 	seed := MakeSeed()
 	size := maxUsed
-	c := newTable(0)
+	c := newTable[string, int](0)
 	ss := make([]string, size)
 	for i := range size {
 		key := str(i)
-		c.add(&key, i, seed.Hash(key))
+		c.add(key, i, HashString(seed, key))
 		ss[i] = str(i)
 	}
 	rand.Shuffle(len(ss), func(i, j int) {
@@ -162,8 +172,8 @@ func BenchmarkGet(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		key := ss[i%size]
-		hash := seed.Hash(key)
-		_, found := c.get(&key, hash)
+		hash := HashString(seed, key)
+		_, found := c.get(key, hash)
 		if !found {
 			b.Fatalf("Key %q should be found", key)
 		}
@@ -0,0 +1,101 @@
+package altmap
+
+import "time"
+
+// AddWithTTL is Add where the item expires after the duration ttl. An
+// expired item is a missing item and is deleted when accessed or swept.
+// The item doesn't expire if ttl is not positive.
+//
+// Adding again the key with Add or AddWithTTL replaces its expiration time.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (oldValue V, ok bool) {
+	var expires int64
+	if ttl > 0 {
+		expires = c.now().Add(ttl).UnixNano()
+	}
+	hash := c.hasher.Hash(c.seed, key)
+	return c.add(c.table(hash), key, value, hash, expires)
+}
+
+// setExpires sets the expiration time of the item in the given slot of t in
+// Unix nanoseconds, or 0 if it doesn't expire. The expiration times of t are
+// allocated by the first item that expires.
+func (t *table[K, V]) setExpires(slot int, expires int64) {
+	if expires != 0 && t.expires == nil {
+		t.expires = make([]int64, t.cap())
+	}
+	if t.expires != nil {
+		t.expires[slot] = expires
+	}
+}
+
+// expired returns true if the item in the given slot of t has expired.
+// Requires t.expires is not nil.
+func (c *Cache[K, V]) expired(t *table[K, V], slot int) bool {
+	e := t.expires[slot]
+	return e != 0 && e <= c.now().UnixNano()
+}
+
+// expire deletes the expired item in the given slot of t, the table of hash
+// or the table being split into it, and calls the eviction callback.
+func (c *Cache[K, V]) expire(t *table[K, V], slot int, hash uint) {
+	key := t.item(slot).key
+	value := c.delAt(t, slot, hash)
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+// Sweep deletes the expired items found in the next n groups of the tables
+// and returns the number of deleted items. Successive calls resume where the
+// previous call stopped and cycle over all the tables, so that the memory
+// of expired items that are never accessed is reclaimed.
+//
+// Sweep may be called periodically, or after each Add to spread the cost.
+func (c *Cache[K, V]) Sweep(n int) int {
+	c.finishSplits()
+	var count int
+	if size := len(c.tables[0].groups); n > len(c.tables)*size {
+		n = len(c.tables) * size
+	}
+	now := c.now().UnixNano()
+	h := &c.sweep
+	for n > 0 {
+		if h.tIdx >= uint(len(c.tables)) {
+			h.tIdx = 0
+		}
+		t := c.tables[h.tIdx]
+		if h.tIdx >= 1<<t.depth {
+			h.next()
+			continue
+		}
+		if t.expires == nil {
+			// no item of the table expires
+			n -= len(t.groups) - h.slot/nItems
+			h.next()
+			continue
+		}
+		for ; h.slot < t.cap() && n > 0; n-- {
+			g := h.slot / nItems
+			for set := t.groups[g].header.FindUsed(); !set.Empty(); set = set.Next() {
+				i := g*nItems + set.Pos()
+				if e := t.expires[i]; e != 0 && e <= now {
+					// the rehash is deferred to keep the items in place
+					key := t.item(i).key
+					value := c.delSlot(t, i)
+					count++
+					if c.onEvict != nil {
+						c.onEvict(key, value)
+					}
+				}
+			}
+			h.slot = (g + 1) * nItems
+		}
+		if h.slot >= t.cap() {
+			if int(t.nTombstones) > t.limits().maxTombstones {
+				c.rehash(t, h.tIdx)
+			}
+			h.next()
+		}
+	}
+	return count
+}
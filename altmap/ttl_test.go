@@ -0,0 +1,145 @@
+package altmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock advanced manually.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestCacheTTL(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	var expired []string
+	c := NewCache[string, int](0, WithClock(clk.now), WithEvictCallback(func(k string, v int) {
+		expired = append(expired, k)
+	}))
+	c.AddWithTTL("a", 1, time.Second)
+	c.AddWithTTL("b", 2, 2*time.Second)
+	c.Add("c", 3)
+
+	clk.t = clk.t.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("expect key a to be expired")
+	}
+	if exp, got := 2, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Fatalf("expect 2 true, got %d %v", v, ok)
+	}
+
+	// replacing the value replaces the expiration time
+	if _, ok := c.Add("b", 20); !ok {
+		t.Fatalf("expect key b to be found")
+	}
+	clk.t = clk.t.Add(time.Hour)
+	if v, ok := c.Get("b"); !ok || v != 20 {
+		t.Fatalf("expect 20 true, got %d %v", v, ok)
+	}
+	c.AddWithTTL("c", 30, time.Second)
+	clk.t = clk.t.Add(time.Second)
+	if _, ok := c.Add("c", 300); ok {
+		t.Fatalf("expect expired key c to be added as a new key")
+	}
+	if exp, got := "a c", strings.Join(expired, " "); exp != got {
+		t.Fatalf("expect expired %q, got %q", exp, got)
+	}
+}
+
+// TestCacheTTLExisting checks that AddWithTTL sets the expiration time of a
+// key added without one, including a key live in a table being split.
+func TestCacheTTLExisting(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[int, int](0, WithClock(clk.now))
+	c.Add(1, 1)
+	c.AddWithTTL(1, 2, time.Second)
+	clk.t = clk.t.Add(2 * time.Second)
+	if v, ok := c.Get(1); ok {
+		t.Fatalf("expect key 1 to be expired, got %d", v)
+	}
+
+	c = NewCache[int, int](0, WithClock(clk.now), WithIncrementalSplit())
+	n := 0
+	for ; len(c.splits) == 0 || c.splits[len(c.splits)-1].halves[0] == nil; n++ {
+		c.Add(n, n)
+	}
+	for i := range n {
+		c.AddWithTTL(i, i, time.Second)
+	}
+	// the expiration times are copied with the items to the halves
+	c.finishSplits()
+	clk.t = clk.t.Add(2 * time.Second)
+	for i := range n {
+		if v, ok := c.Get(i); ok {
+			t.Fatalf("expect key %d to be expired, got %d", i, v)
+		}
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expect empty, got %d", c.Len())
+	}
+}
+
+func TestCacheSweep(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[int, int](0, WithClock(clk.now))
+	if exp, got := 0, c.Sweep(100); exp != got {
+		t.Fatalf("expect %d swept, got %d", exp, got)
+	}
+	const n = 20000
+	for i := range n {
+		if i%2 == 0 {
+			c.AddWithTTL(i, i, time.Minute)
+		} else {
+			c.Add(i, i)
+		}
+	}
+	clk.t = clk.t.Add(time.Minute)
+
+	// sweep in small steps to cycle over all tables
+	var count int
+	for range (len(c.tables)*tableSize + 9) / 10 {
+		count += c.Sweep(10)
+	}
+	if exp, got := n/2, count; exp != got {
+		t.Fatalf("expect %d swept, got %d", exp, got)
+	}
+	if exp, got := n/2, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	for i := range n {
+		if _, ok := c.Get(i); ok != (i%2 == 1) {
+			t.Fatalf("key %d expect found %v, got %v", i, i%2 == 1, ok)
+		}
+	}
+}
+
+func TestCacheTTLWithPolicy(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCache[int, int](100, WithLRU(), WithClock(clk.now))
+	for i := range 100 {
+		c.AddWithTTL(i, i, time.Duration(i+1)*time.Second)
+	}
+	clk.t = clk.t.Add(50 * time.Second)
+	if exp, got := 50, c.Sweep(1000); exp != got {
+		t.Fatalf("expect %d swept, got %d", exp, got)
+	}
+	for i := 100; i < 150; i++ {
+		c.Add(i, i)
+	}
+	if exp, got := 100, c.Len(); exp != got {
+		t.Fatalf("expect len %d, got %d", exp, got)
+	}
+	for i := 50; i < 150; i++ {
+		if _, ok := c.Get(i); !ok {
+			t.Fatalf("expect key %d to be found", i)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package altmapint
+package altmap
 
 import "math/bits"
 
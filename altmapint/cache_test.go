@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"testing"
+
+	"fastmap/altmap"
 )
 
 func TestCacheAddGet(t *testing.T) {
-	var c Cache
+	var c altmap.Cache[int, int]
 	c.Init()
 	ss := []int{}
 	for i := range 5000 {
@@ -43,7 +45,7 @@ func TestCacheAddGet(t *testing.T) {
 }
 
 func TestCacheAddDel(t *testing.T) {
-	var c Cache
+	var c altmap.Cache[int, int]
 	c.Init()
 	ss := []int{}
 	for i := range 5000 {
@@ -99,7 +101,7 @@ func BenchmarkCache2Hit(b *testing.B) {
 		})
 
 		b.Run(fmt.Sprintf("%8d", size), func(b *testing.B) {
-			var c Cache
+			var c altmap.Cache[int, int]
 			c.Init()
 			for i := range size {
 				c.Add(ss[i], i)
@@ -131,7 +133,7 @@ func BenchmarkCache2Miss(b *testing.B) {
 		})
 
 		b.Run(fmt.Sprintf("%8d", size), func(b *testing.B) {
-			var c Cache
+			var c altmap.Cache[int, int]
 			c.Init()
 			for i := range size {
 				c.Add(ss[i], i)